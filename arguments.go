@@ -32,6 +32,10 @@ func processArguments() {
 	flag.BoolVar(&plotMFCC, "plot", false, "plot mfcc coefficients")
 	flag.BoolVar(&listGenerators, "list-generators", false, "list generators available")
 	flag.StringVar(&generator, "generator", "copy", "select the generator to use")
+	flag.BoolVar(&listFormats, "list-formats", false, "list output formats available (os_task_file_format)")
+	flag.StringVar(&book, "book", "", "USFM book code of the recording (GEN, MAT...), for single task mode")
+	flag.IntVar(&chapter, "chapter", 0, "chapter of the recording, for single task mode")
+	flag.StringVar(&level, "level", "", "unit of text of the phrases: phrase, sentence or verse, for single task mode")
 	// Note: if we use BoolVar for help, we still see "pflag: help requested"
 	showHelp = flag.BoolP("help", "h", false, "display help")
 	flag.Parse()
@@ -0,0 +1,123 @@
+package audiodecoders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+// WAV file with a fmt chunk of the given format and the data chunk holding data
+func wavFile(format uint16, bitDepth uint16, channels uint16, sampleRate uint32, data []byte) []byte {
+	var file bytes.Buffer
+	file.WriteString("RIFF")
+	binary.Write(&file, binary.LittleEndian, uint32(36+len(data)))
+	file.WriteString("WAVEfmt ")
+	blockAlign := channels * bitDepth / 8
+	for _, field := range []any{uint32(16), format, channels, sampleRate, sampleRate * uint32(blockAlign), blockAlign, bitDepth} {
+		binary.Write(&file, binary.LittleEndian, field)
+	}
+	file.WriteString("data")
+	binary.Write(&file, binary.LittleEndian, uint32(len(data)))
+	file.Write(data)
+	return file.Bytes()
+}
+
+func TestDecodeWav(t *testing.T) {
+	float32Data := new(bytes.Buffer)
+	binary.Write(float32Data, binary.LittleEndian, []float32{0.25, -1, 0.5})
+
+	tests := []struct {
+		name     string
+		format   uint16
+		bitDepth uint16
+		channels uint16
+		data     []byte
+		want     []float32
+	}{
+		{"8 bit unsigned", wavFormatPcm, 8, 1, []byte{128, 192, 0}, []float32{0, 0.5, -1}},
+		{"16 bit stereo", wavFormatPcm, 16, 2, []byte{0x00, 0x40, 0x00, 0x00, 0x00, 0x80, 0x00, 0xc0}, []float32{0.25, -0.75}},
+		{"24 bit", wavFormatPcm, 24, 1, []byte{0x00, 0x00, 0x40, 0x00, 0x00, 0xc0, 0xff, 0xff, 0xff}, []float32{0.5, -0.5, -1.0 / (1 << 23)}},
+		{"32 bit float", wavFormatFloat, 32, 1, float32Data.Bytes(), []float32{0.25, -1, 0.5}},
+	}
+	for _, test := range tests {
+		audio, err := decodeWav(bytes.NewReader(wavFile(test.format, test.bitDepth, test.channels, 8000, test.data)))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if audio.SampleRate != 8000 || len(audio.Samples) != len(test.want) {
+			t.Errorf("%s: %d samples at %d Hz", test.name, len(audio.Samples), audio.SampleRate)
+			continue
+		}
+		for i, want := range test.want {
+			if math.Abs(float64(audio.Samples[i]-want)) > 1e-7 {
+				t.Errorf("%s: sample %d is %v, want %v", test.name, i, audio.Samples[i], want)
+			}
+		}
+	}
+
+	_, err := decodeWav(bytes.NewReader(wavFile(wavFormatFloat, 64, 1, 8000, make([]byte, 16))))
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("64 bit float: got %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
+
+func sine(frequency float64, sampleRate int, seconds float64) *Audio {
+	samples := make([]float32, int(seconds*float64(sampleRate)))
+	for i := range samples {
+		samples[i] = float32(0.5 * math.Sin(2*math.Pi*frequency*float64(i)/float64(sampleRate)))
+	}
+	return &Audio{Samples: samples, SampleRate: sampleRate}
+}
+
+// Largest difference with the sine the audio should be, away from both ends
+func sineError(audio *Audio, frequency float64) float64 {
+	want := sine(frequency, audio.SampleRate, float64(len(audio.Samples))/float64(audio.SampleRate))
+	margin := audio.SampleRate / 20
+	worst := 0.0
+	for i := margin; i < len(audio.Samples)-margin; i++ {
+		worst = math.Max(worst, math.Abs(float64(audio.Samples[i]-want.Samples[i])))
+	}
+	return worst
+}
+
+func TestResample(t *testing.T) {
+	tests := []struct {
+		name      string
+		from      int
+		to        int
+		frequency float64
+	}{
+		{"down by 2", 44100, 22050, 440},
+		{"down", 48000, 22050, 1000},
+		{"up", 8000, 22050, 440},
+		{"more phases than kept", 22050, 16001, 300},
+	}
+	for _, test := range tests {
+		resampled := sine(test.frequency, test.from, 1).Resample(test.to)
+		if resampled.SampleRate != test.to || len(resampled.Samples) != test.to {
+			t.Errorf("%s: %d samples at %d Hz", test.name, len(resampled.Samples), resampled.SampleRate)
+			continue
+		}
+		if worst := sineError(resampled, test.frequency); worst > 0.01 {
+			t.Errorf("%s: %v away from the sine", test.name, worst)
+		}
+	}
+
+	// Above the new Nyquist frequency, a tone is filtered out instead of folding back
+	resampled := sine(15000, 44100, 1).Resample(22050)
+	peak := 0.0
+	for _, sample := range resampled.Samples[1000 : len(resampled.Samples)-1000] {
+		peak = math.Max(peak, math.Abs(float64(sample)))
+	}
+	if peak > 0.01 {
+		t.Errorf("15 kHz tone resampled to 22050 Hz has a peak of %v", peak)
+	}
+
+	audio := sine(440, 22050, 0.1)
+	if audio.Resample(22050) != audio {
+		t.Error("audio copied when the rate doesn't change")
+	}
+}
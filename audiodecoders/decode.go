@@ -0,0 +1,110 @@
+package audiodecoders
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Decoded audio, mono with samples scaled to [-1, 1]
+//
+// Channels are averaged while decoding and samples are kept as float32, so a long
+// recording never has a full multichannel or float64 copy in memory.
+type Audio struct {
+	Samples    []float32
+	SampleRate int
+}
+
+var ErrUnsupportedFormat = errors.New("unsupported audio format")
+
+type decodeFunc func(reader io.ReadSeeker) (*Audio, error)
+
+// Decodes a WAV, FLAC, MP3 or OGG/Vorbis file to mono, the format is detected from the first bytes of the file
+func DecodeFile(path string) (*Audio, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	header := make([]byte, 12)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	decode := detectFormat(header[:n], filepath.Ext(path))
+	if decode == nil {
+		return nil, fmt.Errorf("%s: %w", path, ErrUnsupportedFormat)
+	}
+
+	audio, err := decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(audio.Samples) == 0 {
+		return nil, fmt.Errorf("%s: no audio samples decoded", path)
+	}
+	return audio, nil
+}
+
+func detectFormat(header []byte, extension string) decodeFunc {
+	switch {
+	case len(header) >= 12 && bytes.Equal(header[0:4], []byte("RIFF")) && bytes.Equal(header[8:12], []byte("WAVE")):
+		return decodeWav
+	case bytes.HasPrefix(header, []byte("fLaC")):
+		return decodeFlac
+	case bytes.HasPrefix(header, []byte("OggS")):
+		return decodeOgg
+	case bytes.HasPrefix(header, []byte("ID3")):
+		// An ID3 tag may precede both MP3 and FLAC data
+		if strings.EqualFold(extension, ".flac") {
+			return decodeFlac
+		}
+		return decodeMp3
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0:
+		// MPEG audio frame sync
+		return decodeMp3
+	}
+	return nil
+}
+
+// Averages interleaved samples into mono samples as they are decoded
+type downmixer struct {
+	channels int
+	channel  int
+	sum      float64
+	samples  []float32
+}
+
+func newDownmixer(channels int, expectedFrames int) *downmixer {
+	return &downmixer{channels: max(channels, 1), samples: make([]float32, 0, max(expectedFrames, 0))}
+}
+
+// Adds the next interleaved sample, completing a mono sample once every channel has been added
+func (mixer *downmixer) add(sample float64) {
+	mixer.sum += sample
+	mixer.channel++
+	if mixer.channel == mixer.channels {
+		mixer.samples = append(mixer.samples, float32(mixer.sum/float64(mixer.channels)))
+		mixer.sum = 0
+		mixer.channel = 0
+	}
+}
+
+// Decodes any supported file and writes it as a mono 16 bit PCM WAV file at the given sample rate
+func ConvertToWav(inputPath string, outputPath string, sampleRate int) error {
+	audio, err := DecodeFile(inputPath)
+	if err != nil {
+		return err
+	}
+
+	return audio.Resample(sampleRate).WriteWav(outputPath)
+}
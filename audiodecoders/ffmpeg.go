@@ -0,0 +1,138 @@
+package audiodecoders
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Size of the header of the WAV files written by ffmpeg, a file this small holds no audio
+const wavHeaderSize = 44
+
+// Paths of the executables, which only need to be names when they are in the PATH
+type Ffmpeg struct {
+	FfmpegPath  string
+	FfprobePath string
+}
+
+func NewFfmpeg() *Ffmpeg {
+	return &Ffmpeg{FfmpegPath: "ffmpeg", FfprobePath: "ffprobe"}
+}
+
+// Whether the ffmpeg executable can be found, ffprobe being optional
+func (ffmpeg *Ffmpeg) Available() bool {
+	_, err := exec.LookPath(ffmpeg.FfmpegPath)
+	return err == nil
+}
+
+// Description of the first audio stream of a file
+type ProbeInfo struct {
+	Codec      string
+	Channels   int
+	SampleRate int
+	Duration   float64 // In seconds
+}
+
+// Describes the first audio stream of a file with ffprobe, failing when there is none
+func (ffmpeg *Ffmpeg) Probe(ctx context.Context, path string) (*ProbeInfo, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg.FfprobePath, "-v", "error", "-select_streams", "a:0",
+		"-show_entries", "stream=codec_name,channels,sample_rate:format=duration", "-of", "json", path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, commandError(ctx, "ffprobe", err, &stderr)
+	}
+
+	var probed struct {
+		Streams []struct {
+			CodecName  string `json:"codec_name"`
+			Channels   int    `json:"channels"`
+			SampleRate string `json:"sample_rate"`
+		} `json:"streams"`
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &probed); err != nil {
+		return nil, fmt.Errorf("ffprobe: %w", err)
+	}
+	if len(probed.Streams) == 0 {
+		return nil, fmt.Errorf("ffprobe: %s has no audio stream", path)
+	}
+
+	stream := probed.Streams[0]
+	info := &ProbeInfo{Codec: stream.CodecName, Channels: stream.Channels}
+	info.SampleRate, _ = strconv.Atoi(stream.SampleRate)
+	info.Duration, _ = strconv.ParseFloat(probed.Format.Duration, 64)
+	if info.Duration <= 0 {
+		return nil, fmt.Errorf("ffprobe: %s has no audio (duration %s)", path, probed.Format.Duration)
+	}
+	return info, nil
+}
+
+// Converts any file ffmpeg can read to a mono 16 bit PCM WAV file at the given sample rate
+//
+// progress, when not nil, is called with the number of seconds converted so far. The
+// conversion is stopped when ctx is done. Errors include what ffmpeg wrote to stderr.
+func (ffmpeg *Ffmpeg) ConvertToWav(ctx context.Context, inputPath string, outputPath string, sampleRate int, progress func(seconds float64)) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg.FfmpegPath, "-nostdin", "-y", "-v", "error", "-progress", "pipe:1",
+		"-i", inputPath, "-acodec", "pcm_s16le", "-ac", "1", "-ar", strconv.Itoa(sampleRate), outputPath)
+	cmd.Stderr = &stderr
+	// Don't wait for the output to be closed once ffmpeg has been stopped by ctx
+	cmd.WaitDelay = time.Second
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return commandError(ctx, "ffmpeg", err, &stderr)
+	}
+	readProgress(stdout, progress)
+	if err := cmd.Wait(); err != nil {
+		return commandError(ctx, "ffmpeg", err, &stderr)
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return fmt.Errorf("ffmpeg: %w", err)
+	}
+	if info.Size() <= wavHeaderSize {
+		return commandError(ctx, "ffmpeg", fmt.Errorf("no audio written to %s", outputPath), &stderr)
+	}
+	return nil
+}
+
+// Reads the key=value lines written by `-progress`, reporting out_time_us in seconds
+func readProgress(reader io.Reader, progress func(seconds float64)) {
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), "=")
+		if !found || key != "out_time_us" || progress == nil {
+			continue
+		}
+		if microseconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			progress(float64(microseconds) / 1e6)
+		}
+	}
+}
+
+func commandError(ctx context.Context, name string, err error, stderr *bytes.Buffer) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%s: %w", name, ctxErr)
+	}
+	if message := strings.TrimSpace(stderr.String()); message != "" {
+		return fmt.Errorf("%s: %w: %s", name, err, message)
+	}
+	return fmt.Errorf("%s: %w", name, err)
+}
@@ -0,0 +1,36 @@
+package audiodecoders
+
+import (
+	"io"
+
+	"github.com/mewkiz/flac"
+)
+
+func decodeFlac(reader io.ReadSeeker) (*Audio, error) {
+	stream, err := flac.New(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := int(stream.Info.NChannels)
+	scale := float64(int64(1) << (stream.Info.BitsPerSample - 1))
+	mixer := newDownmixer(channels, int(stream.Info.NSamples))
+
+	for {
+		frame, err := stream.ParseNext()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < int(frame.BlockSize); i++ {
+			for _, subframe := range frame.Subframes {
+				mixer.add(float64(subframe.Samples[i]) / scale)
+			}
+		}
+	}
+
+	return &Audio{Samples: mixer.samples, SampleRate: int(stream.Info.SampleRate)}, nil
+}
@@ -0,0 +1,36 @@
+package audiodecoders
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// Bytes of decoded MP3 read at once (16 bit stereo frames)
+const mp3BlockSize = 16384
+
+func decodeMp3(reader io.ReadSeeker) (*Audio, error) {
+	decoder, err := mp3.NewDecoder(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// The decoder always outputs 16 bit little endian stereo
+	mixer := newDownmixer(2, int(decoder.Length()/4))
+	block := make([]byte, mp3BlockSize)
+	for {
+		n, err := io.ReadFull(decoder, block)
+		for i := 0; i+1 < n; i += 2 {
+			mixer.add(float64(int16(binary.LittleEndian.Uint16(block[i:]))) / 32768)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Audio{Samples: mixer.samples, SampleRate: decoder.SampleRate()}, nil
+}
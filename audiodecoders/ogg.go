@@ -0,0 +1,34 @@
+package audiodecoders
+
+import (
+	"io"
+
+	"github.com/jfreymuth/oggvorbis"
+)
+
+// Samples of decoded Vorbis read at once
+const oggBlockSize = 8192
+
+func decodeOgg(reader io.ReadSeeker) (*Audio, error) {
+	decoder, err := oggvorbis.NewReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	mixer := newDownmixer(decoder.Channels(), int(decoder.Length()))
+	block := make([]float32, oggBlockSize*max(decoder.Channels(), 1))
+	for {
+		n, err := decoder.Read(block)
+		for _, sample := range block[:n] {
+			mixer.add(float64(sample))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Audio{Samples: mixer.samples, SampleRate: decoder.SampleRate()}, nil
+}
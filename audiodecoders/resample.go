@@ -0,0 +1,96 @@
+package audiodecoders
+
+import "math"
+
+// Number of zero crossings of the sinc kernel kept on each side of a sample
+const resampleZeroCrossings = 16
+
+// Most fractional positions the kernel is computed for. Ratios needing more (unusual
+// rates) use the nearest of this many positions.
+const resampleMaxPhases = 1024
+
+// Converts the audio to another sample rate with a Hann windowed sinc interpolator
+//
+// When lowering the rate, the kernel is stretched so it also acts as a low-pass filter
+// at the new Nyquist frequency, which prevents aliasing.
+//
+// The rates have a rational ratio, so output samples fall at a limited number of fractional
+// positions (phases) between input samples. The kernel weights of every phase are computed
+// once (polyphase filter) instead of for every output sample.
+func (audio *Audio) Resample(sampleRate int) *Audio {
+	if sampleRate == audio.SampleRate || len(audio.Samples) == 0 {
+		return audio
+	}
+
+	// Output sample n is at input position n * step / up
+	divisor := gcd(sampleRate, audio.SampleRate)
+	up, step := sampleRate/divisor, audio.SampleRate/divisor
+	phases := min(up, resampleMaxPhases)
+
+	cutoff := math.Min(1, float64(up)/float64(step)) // Relative to the input Nyquist frequency
+	halfWidth := float64(resampleZeroCrossings) / cutoff
+	halfTaps := int(math.Ceil(halfWidth))
+	taps := 2 * halfTaps
+
+	// kernel[p*taps+t] weights input sample floor(position)-halfTaps+1+t for phase p
+	kernel := make([]float32, phases*taps)
+	for p := 0; p < phases; p++ {
+		fraction := float64(p) / float64(phases)
+		for t := 0; t < taps; t++ {
+			x := fraction - float64(t-halfTaps+1)
+			if math.Abs(x) < halfWidth {
+				kernel[p*taps+t] = float32(cutoff * sinc(cutoff*x) * 0.5 * (1 + math.Cos(math.Pi*x/halfWidth)))
+			}
+		}
+	}
+
+	inputFrames := len(audio.Samples)
+	outputFrames := int(int64(inputFrames) * int64(up) / int64(step))
+	samples := make([]float32, outputFrames)
+	for n := range samples {
+		position := int64(n) * int64(step)
+		base := int(position / int64(up))
+		phase := int(position % int64(up))
+		if phases < up {
+			// Nearest phase, which may be the first one of the next input sample
+			phase = int(math.Round(float64(phase) * float64(phases) / float64(up)))
+			if phase == phases {
+				base++
+				phase = 0
+			}
+		}
+
+		weights := kernel[phase*taps : (phase+1)*taps]
+		first := base - halfTaps + 1
+		sum := float32(0)
+		if first >= 0 && first+taps <= inputFrames {
+			for t, sample := range audio.Samples[first : first+taps] {
+				sum += weights[t] * sample
+			}
+		} else {
+			// Near the ends, samples outside the audio are silence
+			for t, weight := range weights {
+				if k := first + t; k >= 0 && k < inputFrames {
+					sum += weight * audio.Samples[k]
+				}
+			}
+		}
+		samples[n] = sum
+	}
+
+	return &Audio{Samples: samples, SampleRate: sampleRate}
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+func gcd(a int, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
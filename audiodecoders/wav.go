@@ -0,0 +1,94 @@
+package audiodecoders
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+)
+
+// Format codes of the WAV fmt chunk
+const (
+	wavFormatPcm   = 1
+	wavFormatFloat = 3
+)
+
+// Frames read or written at once
+const wavBlockFrames = 8192
+
+func decodeWav(reader io.ReadSeeker) (*Audio, error) {
+	decoder := wav.NewDecoder(reader)
+	if !decoder.IsValidFile() {
+		return nil, fmt.Errorf("invalid WAV file")
+	}
+
+	// go-audio returns every sample as an int, which still has to be interpreted according
+	// to the format. Anything else (64 bit float, compressed, extensible) is left to ffmpeg.
+	var convert func(sample int) float64
+	bitDepth := int(decoder.BitDepth)
+	switch {
+	case decoder.WavAudioFormat == wavFormatPcm && bitDepth == 8:
+		// 8 bit samples are unsigned
+		convert = func(sample int) float64 { return float64(sample-128) / 128 }
+	case decoder.WavAudioFormat == wavFormatPcm && (bitDepth == 16 || bitDepth == 24 || bitDepth == 32):
+		scale := float64(int64(1) << (bitDepth - 1))
+		convert = func(sample int) float64 { return float64(sample) / scale }
+	case decoder.WavAudioFormat == wavFormatFloat && bitDepth == 32:
+		// Read as a little endian int32, which holds the bits of the float
+		convert = func(sample int) float64 { return float64(math.Float32frombits(uint32(int32(sample)))) }
+	default:
+		return nil, fmt.Errorf("WAV format %d with %d bits per sample: %w", decoder.WavAudioFormat, bitDepth, ErrUnsupportedFormat)
+	}
+
+	if err := decoder.FwdToPCM(); err != nil {
+		return nil, err
+	}
+	channels := int(decoder.NumChans)
+	mixer := newDownmixer(channels, int(decoder.PCMLen())/max(bitDepth/8*channels, 1))
+
+	// Read block by block, so the whole file is never held as ints
+	buffer := &audio.IntBuffer{Data: make([]int, wavBlockFrames*max(channels, 1))}
+	for {
+		n, err := decoder.PCMBuffer(buffer)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			break
+		}
+		for _, sample := range buffer.Data[:n] {
+			mixer.add(convert(sample))
+		}
+	}
+
+	return &Audio{Samples: mixer.samples, SampleRate: int(decoder.SampleRate)}, nil
+}
+
+// Writes the audio as a mono 16 bit PCM WAV file
+func (a *Audio) WriteWav(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	encoder := wav.NewEncoder(file, a.SampleRate, 16, 1, wavFormatPcm)
+	buffer := &audio.IntBuffer{
+		Format:         &audio.Format{NumChannels: 1, SampleRate: a.SampleRate},
+		SourceBitDepth: 16,
+	}
+	for start := 0; start < len(a.Samples); start += wavBlockFrames {
+		block := a.Samples[start:min(start+wavBlockFrames, len(a.Samples))]
+		buffer.Data = buffer.Data[:0]
+		for _, sample := range block {
+			buffer.Data = append(buffer.Data, int(max(-1, min(1, sample))*32767))
+		}
+		if err := encoder.Write(buffer); err != nil {
+			return err
+		}
+	}
+	return encoder.Close()
+}
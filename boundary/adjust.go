@@ -0,0 +1,209 @@
+package boundary
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+	"github.com/sillsdev/go-aeneas/vad"
+)
+
+type Algorithm string
+
+const (
+	// Boundaries are left where the alignment put them
+	AlgorithmAuto Algorithm = "auto"
+	// Boundary set the given number of seconds after the current fragment ends (start of the pause)
+	AlgorithmAfterCurrent Algorithm = "aftercurrent"
+	// Boundary set the given number of seconds before the next fragment begins (end of the pause)
+	AlgorithmBeforeNext Algorithm = "beforenext"
+	// Every boundary shifted by the given number of seconds, which may be negative
+	AlgorithmOffset Algorithm = "offset"
+	// Boundary set at the given percentage of the pause
+	AlgorithmPercent Algorithm = "percent"
+	// Fragments read faster than the given characters per second borrow time from their
+	// neighbours, as long as the neighbours stay under the limit
+	AlgorithmRate Algorithm = "rate"
+	// Like AlgorithmRate, but neighbours may be pushed over the limit
+	AlgorithmRateAggressive Algorithm = "rateaggressive"
+)
+
+// Aligned phrase, in frames of the input audio (End is exclusive)
+type Fragment struct {
+	Begin int
+	End   int
+	// Length of the text, only used by the rate algorithms
+	Characters int
+}
+
+// Settings of the adjustment, lengths are in frames
+type Options struct {
+	Algorithm Algorithm
+	// Seconds of aftercurrent, beforenext and offset converted to frames
+	Offset int
+	// Percentage of the pause for AlgorithmPercent, between 0 and 100
+	Percent float64
+	// Maximum characters per frame for the rate algorithms
+	MaxRate float64
+	// Pauses shorter than this are not used to place boundaries
+	NonspeechMin int
+}
+
+// Reads the options from the task parameters, using the same keys as aeneas:
+// `task_adjust_boundary_algorithm` selects the algorithm (auto by default) and
+// `task_adjust_boundary_<algorithm>_value` its value (seconds, percent or characters
+// per second). `task_adjust_boundary_nonspeech_min` (1 second) is the shortest pause used.
+// frameRate converts the values from seconds to frames.
+func ParseOptions(parameters *datatypes.Parameters, frameRate float64) (*Options, error) {
+	options := &Options{Algorithm: Algorithm(parameters.Get("task_adjust_boundary_algorithm"))}
+
+	nonspeechMin, err := parameters.GetFloat("task_adjust_boundary_nonspeech_min", 1)
+	if err != nil {
+		return nil, err
+	}
+	options.NonspeechMin = int(math.Round(nonspeechMin * frameRate))
+
+	switch options.Algorithm {
+	case "":
+		options.Algorithm = AlgorithmAuto
+	case AlgorithmAuto:
+	case AlgorithmAfterCurrent, AlgorithmBeforeNext, AlgorithmOffset:
+		seconds, err := parameters.GetFloat(valueKey(options.Algorithm), 0)
+		if err != nil {
+			return nil, err
+		}
+		options.Offset = int(math.Round(seconds * frameRate))
+	case AlgorithmPercent:
+		if options.Percent, err = parameters.GetFloat(valueKey(options.Algorithm), 50); err != nil {
+			return nil, err
+		}
+		if options.Percent < 0 || options.Percent > 100 {
+			return nil, fmt.Errorf("parameter %s: %v is not between 0 and 100", valueKey(options.Algorithm), options.Percent)
+		}
+	case AlgorithmRate, AlgorithmRateAggressive:
+		// aeneas uses the same value key for both rate algorithms
+		rate, err := parameters.GetFloat(valueKey(AlgorithmRate), 0)
+		if err != nil {
+			return nil, err
+		}
+		if rate <= 0 {
+			return nil, fmt.Errorf("parameter %s: a positive rate is required", valueKey(AlgorithmRate))
+		}
+		options.MaxRate = rate / frameRate
+	default:
+		return nil, fmt.Errorf("unknown boundary adjustment algorithm %q", options.Algorithm)
+	}
+
+	return options, nil
+}
+
+func valueKey(algorithm Algorithm) string {
+	return fmt.Sprintf("task_adjust_boundary_%s_value", algorithm)
+}
+
+// Moves the boundaries between consecutive fragments, in place
+//
+// The begin of the first fragment and the end of the last one are never moved. nonspeech
+// are the pauses found by the voice activity detector, used by aftercurrent, beforenext
+// and percent: a boundary is only moved when a pause of at least NonspeechMin frames lies
+// between the two fragments (or contains the boundary), the fragments then meet inside it.
+func Adjust(fragments []Fragment, nonspeech []vad.Interval, options *Options) {
+	switch options.Algorithm {
+	case AlgorithmAfterCurrent:
+		adjustInPauses(fragments, nonspeech, options.NonspeechMin, func(pause vad.Interval) int {
+			return min(pause.Start+options.Offset, pause.End)
+		})
+	case AlgorithmBeforeNext:
+		adjustInPauses(fragments, nonspeech, options.NonspeechMin, func(pause vad.Interval) int {
+			return max(pause.End-options.Offset, pause.Start)
+		})
+	case AlgorithmPercent:
+		adjustInPauses(fragments, nonspeech, options.NonspeechMin, func(pause vad.Interval) int {
+			return pause.Start + int(math.Round(float64(pause.End-pause.Start)*options.Percent/100))
+		})
+	case AlgorithmOffset:
+		for i := 0; i+1 < len(fragments); i++ {
+			current, next := &fragments[i], &fragments[i+1]
+			current.End = clamp(current.End+options.Offset, current.Begin, next.End)
+			next.Begin = clamp(next.Begin+options.Offset, current.Begin, next.End)
+		}
+	case AlgorithmRate, AlgorithmRateAggressive:
+		adjustRate(fragments, options.MaxRate, options.Algorithm == AlgorithmRateAggressive)
+	}
+}
+
+func adjustInPauses(fragments []Fragment, nonspeech []vad.Interval, nonspeechMin int, position func(pause vad.Interval) int) {
+	for i := 0; i+1 < len(fragments); i++ {
+		current, next := &fragments[i], &fragments[i+1]
+		pause, ok := findPause(nonspeech, nonspeechMin, current.End, next.Begin)
+		if !ok {
+			continue
+		}
+		boundary := clamp(position(pause), current.Begin, next.End)
+		current.End = boundary
+		next.Begin = boundary
+	}
+}
+
+// Longest pause of at least nonspeechMin frames touching the frames between end and begin
+func findPause(nonspeech []vad.Interval, nonspeechMin int, end int, begin int) (vad.Interval, bool) {
+	from, to := min(end, begin), max(end, begin)
+	best := vad.Interval{}
+	found := false
+	for _, pause := range nonspeech {
+		length := pause.End - pause.Start
+		if length < nonspeechMin || pause.End < from || pause.Start > to {
+			continue
+		}
+		if !found || length > best.End-best.Start {
+			best = pause
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Lengthens the fragments read faster than maxRate characters per frame, first into the gap
+// and the spare time of the next fragment, then into the ones of the previous fragment
+func adjustRate(fragments []Fragment, maxRate float64, aggressive bool) {
+	// Frames a fragment can give away, all but one of them when aggressive
+	spare := func(fragment *Fragment) int {
+		if aggressive {
+			return max(fragment.End-fragment.Begin-1, 0)
+		}
+		return max(fragment.End-fragment.Begin-requiredFrames(fragment, maxRate), 0)
+	}
+
+	for i := range fragments {
+		current := &fragments[i]
+		missing := requiredFrames(current, maxRate) - (current.End - current.Begin)
+		if missing <= 0 {
+			continue
+		}
+
+		if i+1 < len(fragments) {
+			next := &fragments[i+1]
+			gap := max(next.Begin-current.End, 0)
+			taken := min(missing, gap+spare(next))
+			current.End += taken
+			next.Begin = max(next.Begin, current.End)
+			missing -= taken
+		}
+		if missing > 0 && i > 0 {
+			previous := &fragments[i-1]
+			gap := max(current.Begin-previous.End, 0)
+			taken := min(missing, gap+spare(previous))
+			current.Begin -= taken
+			previous.End = min(previous.End, current.Begin)
+		}
+	}
+}
+
+// Frames needed to read the fragment at maxRate characters per frame
+func requiredFrames(fragment *Fragment, maxRate float64) int {
+	return int(math.Ceil(float64(fragment.Characters) / maxRate))
+}
+
+func clamp(value int, low int, high int) int {
+	return max(low, min(value, high))
+}
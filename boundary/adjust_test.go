@@ -0,0 +1,97 @@
+package boundary
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+	"github.com/sillsdev/go-aeneas/vad"
+)
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		parameters string
+		want       Options
+	}{
+		{"task_language=eng", Options{Algorithm: AlgorithmAuto, NonspeechMin: 100}},
+		{"task_adjust_boundary_algorithm=aftercurrent|task_adjust_boundary_aftercurrent_value=0.5", Options{Algorithm: AlgorithmAfterCurrent, Offset: 50, NonspeechMin: 100}},
+		{"task_adjust_boundary_algorithm=offset|task_adjust_boundary_offset_value=-0.2", Options{Algorithm: AlgorithmOffset, Offset: -20, NonspeechMin: 100}},
+		{"task_adjust_boundary_algorithm=percent|task_adjust_boundary_nonspeech_min=0.3", Options{Algorithm: AlgorithmPercent, Percent: 50, NonspeechMin: 30}},
+		{"task_adjust_boundary_algorithm=rateaggressive|task_adjust_boundary_rate_value=20", Options{Algorithm: AlgorithmRateAggressive, MaxRate: 0.2, NonspeechMin: 100}},
+	}
+	for _, test := range tests {
+		options, err := ParseOptions(datatypes.ParseParameters(test.parameters), 100)
+		if err != nil {
+			t.Errorf("%s: %v", test.parameters, err)
+			continue
+		}
+		if *options != test.want {
+			t.Errorf("%s: got %+v, want %+v", test.parameters, *options, test.want)
+		}
+	}
+}
+
+func TestParseOptionsErrors(t *testing.T) {
+	for _, parameters := range []string{
+		"task_adjust_boundary_algorithm=middle",
+		"task_adjust_boundary_algorithm=percent|task_adjust_boundary_percent_value=120",
+		"task_adjust_boundary_algorithm=rate",
+		"task_adjust_boundary_algorithm=beforenext|task_adjust_boundary_beforenext_value=soon",
+	} {
+		if _, err := ParseOptions(datatypes.ParseParameters(parameters), 100); err == nil {
+			t.Errorf("%s: no error", parameters)
+		}
+	}
+}
+
+func TestAdjust(t *testing.T) {
+	// A short pause inside the first boundary and a long one between the last two fragments
+	fragments := []Fragment{{Begin: 0, End: 10}, {Begin: 12, End: 20}, {Begin: 30, End: 40}}
+	nonspeech := []vad.Interval{{Start: 9, End: 14}, {Start: 20, End: 30}}
+
+	tests := []struct {
+		name    string
+		options Options
+		want    []Fragment
+	}{
+		{"auto", Options{Algorithm: AlgorithmAuto}, []Fragment{{0, 10, 0}, {12, 20, 0}, {30, 40, 0}}},
+		{"aftercurrent", Options{Algorithm: AlgorithmAfterCurrent, Offset: 1, NonspeechMin: 2}, []Fragment{{0, 10, 0}, {10, 21, 0}, {21, 40, 0}}},
+		{"beforenext", Options{Algorithm: AlgorithmBeforeNext, Offset: 1, NonspeechMin: 2}, []Fragment{{0, 13, 0}, {13, 29, 0}, {29, 40, 0}}},
+		{"percent", Options{Algorithm: AlgorithmPercent, Percent: 50, NonspeechMin: 2}, []Fragment{{0, 12, 0}, {12, 25, 0}, {25, 40, 0}}},
+		{"short pause ignored", Options{Algorithm: AlgorithmPercent, Percent: 50, NonspeechMin: 6}, []Fragment{{0, 10, 0}, {12, 25, 0}, {25, 40, 0}}},
+		{"offset", Options{Algorithm: AlgorithmOffset, Offset: 2}, []Fragment{{0, 12, 0}, {14, 22, 0}, {32, 40, 0}}},
+		{"offset clamped", Options{Algorithm: AlgorithmOffset, Offset: -15}, []Fragment{{0, 0, 0}, {0, 5, 0}, {15, 40, 0}}},
+	}
+	for _, test := range tests {
+		adjusted := append([]Fragment(nil), fragments...)
+		Adjust(adjusted, nonspeech, &test.options)
+		if !reflect.DeepEqual(adjusted, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, adjusted, test.want)
+		}
+	}
+}
+
+func TestAdjustRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		aggressive bool
+		fragments  []Fragment
+		want       []Fragment
+	}{
+		{"from the next fragment", false, []Fragment{{0, 5, 10}, {5, 20, 5}, {20, 30, 5}}, []Fragment{{0, 10, 10}, {10, 20, 5}, {20, 30, 5}}},
+		{"next fragment kept under the rate", false, []Fragment{{0, 5, 10}, {5, 12, 6}, {12, 30, 2}}, []Fragment{{0, 6, 10}, {6, 12, 6}, {12, 30, 2}}},
+		{"next fragment pushed over the rate", true, []Fragment{{0, 5, 10}, {5, 12, 6}, {12, 30, 2}}, []Fragment{{0, 10, 10}, {10, 16, 6}, {16, 30, 2}}},
+		{"from the previous fragment", false, []Fragment{{0, 10, 2}, {10, 12, 10}}, []Fragment{{0, 2, 2}, {2, 12, 10}}},
+		{"from the gap", false, []Fragment{{0, 5, 8}, {10, 20, 5}}, []Fragment{{0, 8, 8}, {10, 20, 5}}},
+	}
+	for _, test := range tests {
+		options := &Options{Algorithm: AlgorithmRate, MaxRate: 1}
+		if test.aggressive {
+			options.Algorithm = AlgorithmRateAggressive
+		}
+		Adjust(test.fragments, nil, options)
+		if !reflect.DeepEqual(test.fragments, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, test.fragments, test.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package datatypes
+
+// USFM 3 book identifiers (https://ubsicap.github.io/usfm/identification/books.html)
+var usfmBooks = map[string]bool{}
+
+func init() {
+	codes := []string{
+		// Old Testament
+		"GEN", "EXO", "LEV", "NUM", "DEU", "JOS", "JDG", "RUT", "1SA", "2SA", "1KI", "2KI", "1CH", "2CH",
+		"EZR", "NEH", "EST", "JOB", "PSA", "PRO", "ECC", "SNG", "ISA", "JER", "LAM", "EZK", "DAN", "HOS",
+		"JOL", "AMO", "OBA", "JON", "MIC", "NAM", "HAB", "ZEP", "HAG", "ZEC", "MAL",
+		// New Testament
+		"MAT", "MRK", "LUK", "JHN", "ACT", "ROM", "1CO", "2CO", "GAL", "EPH", "PHP", "COL", "1TH", "2TH",
+		"1TI", "2TI", "TIT", "PHM", "HEB", "JAS", "1PE", "2PE", "1JN", "2JN", "3JN", "JUD", "REV",
+		// Deuterocanonical and other books
+		"TOB", "JDT", "ESG", "WIS", "SIR", "BAR", "LJE", "S3Y", "SUS", "BEL", "1MA", "2MA", "3MA", "4MA",
+		"1ES", "2ES", "MAN", "PS2", "ODA", "PSS", "JSA", "JDB", "TBS", "SST", "DNT", "BLT", "EZA", "5EZ",
+		"6EZ", "DAG", "PS3", "2BA", "LBA", "JUB", "ENO", "1MQ", "2MQ", "3MQ", "REP", "4BA", "LAO",
+		// Peripheral books
+		"FRT", "INT", "BAK", "CNC", "GLO", "TDX", "OTH", "XXA", "XXB", "XXC", "XXD", "XXE", "XXF", "XXG",
+	}
+	for _, code := range codes {
+		usfmBooks[code] = true
+	}
+}
+
+func IsUsfmBook(code string) bool {
+	return usfmBooks[code]
+}
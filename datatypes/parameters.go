@@ -1,6 +1,8 @@
 package datatypes
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -14,6 +16,34 @@ func (p Parameters) Get(key string) string {
 	return p.m[key]
 }
 
+// Returns the parameter as a float, or defaultValue when it is not set
+func (p Parameters) GetFloat(key string, defaultValue float64) (float64, error) {
+	value, ok := p.m[key]
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s: %w", key, err)
+	}
+	return parsed, nil
+}
+
+// Returns the parameter as an int, or defaultValue when it is not set
+func (p Parameters) GetInt(key string, defaultValue int) (int, error) {
+	value, ok := p.m[key]
+	if !ok || value == "" {
+		return defaultValue, nil
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s: %w", key, err)
+	}
+	return parsed, nil
+}
+
 func ParseParameters(parameterString string) *Parameters {
 	subParameters := strings.Split(parameterString, "|")
 	mapParameters := make(map[string]string)
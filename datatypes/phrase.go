@@ -1,10 +1,98 @@
 package datatypes
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
+
+// Formats of the phrase file, selected with `is_text_type`
+const (
+	// One `id|text` phrase per line
+	TextTypeParsed = "parsed"
+	// One phrase per line, ids are generated
+	TextTypePlain = "plain"
+	// One phrase per paragraph (paragraphs are separated by blank lines), with one child
+	// per line (sentence) and one grandchild per word, ids are generated
+	TextTypeMultilevelPlain = "mplain"
+	// Elements of an XHTML document selected by id or class, see ParseUnparsedText
+	TextTypeUnparsed = "unparsed"
+	// Elements of an XHTML document selected by id at each level, see ParseMultilevelUnparsedText
+	TextTypeMultilevelUnparsed = "munparsed"
+)
+
+// Format of the generated ids (`os_task_file_id_regex`), same as aeneas
+const DefaultIdFormat = "f%06d"
+
+// Formats of the generated ids of each level of multilevel text, appended to the id of the parent
+// (p000001, p000001s000001, p000001s000001w000001)
+var MultilevelIdFormats = []string{"p%06d", "s%06d", "w%06d"}
 
 type Phrase struct {
 	PhraseIndex string
 	PhraseText  string
+	// Finer phrases of multilevel text, aligned inside this one
+	Children []*Phrase
+}
+
+// Phrase of a plain text file, number is the number of the phrase (starting at 1) formatted with idFormat
+func NewPlainPhrase(line string, number int, idFormat string) *Phrase {
+	return &Phrase{
+		PhraseIndex: fmt.Sprintf(idFormat, number),
+		PhraseText:  strings.TrimSpace(line),
+	}
+}
+
+// Blank lines of a plain text file have no text to synthesize and are skipped
+func IsBlankLine(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// Paragraphs of a multilevel plain text file, each with its sentences and their words as children
+//
+// The text of a paragraph is its sentences, one per line.
+func ParseMultilevelPlainText(content string) []*Phrase {
+	paragraphs := make([]*Phrase, 0)
+	var paragraph *Phrase
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			paragraph = nil
+			continue
+		}
+		if paragraph == nil {
+			paragraph = &Phrase{PhraseIndex: fmt.Sprintf(MultilevelIdFormats[0], len(paragraphs)+1)}
+			paragraphs = append(paragraphs, paragraph)
+		}
+
+		sentence := &Phrase{
+			PhraseIndex: paragraph.PhraseIndex + fmt.Sprintf(MultilevelIdFormats[1], len(paragraph.Children)+1),
+			PhraseText:  line,
+		}
+		for i, word := range strings.Fields(line) {
+			sentence.Children = append(sentence.Children, &Phrase{
+				PhraseIndex: sentence.PhraseIndex + fmt.Sprintf(MultilevelIdFormats[2], i+1),
+				PhraseText:  word,
+			})
+		}
+		paragraph.Children = append(paragraph.Children, sentence)
+	}
+
+	for _, paragraph := range paragraphs {
+		lines := make([]string, 0, len(paragraph.Children))
+		for _, sentence := range paragraph.Children {
+			lines = append(lines, sentence.PhraseText)
+		}
+		paragraph.PhraseText = strings.Join(lines, "\n")
+	}
+	return paragraphs
+}
+
+// Checks that idFormat is a prefix followed by a single integer verb, like f%06d
+func CheckIdFormat(idFormat string) error {
+	if strings.Count(idFormat, "%") != 1 || strings.Contains(fmt.Sprintf(idFormat, 1), "%!") {
+		return fmt.Errorf("id format %q must contain a single integer verb such as %%06d", idFormat)
+	}
+	return nil
 }
 
 func ParsePhrase(phraseLine string) (*Phrase, error) {
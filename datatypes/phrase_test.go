@@ -0,0 +1,44 @@
+package datatypes
+
+import (
+	"strings"
+	"testing"
+)
+
+// One `id=text` line per phrase, children indented under their parent
+func describePhrases(phrases []*Phrase, indent string) string {
+	text := ""
+	for _, phrase := range phrases {
+		text += indent + phrase.PhraseIndex + "=" + strings.ReplaceAll(phrase.PhraseText, "\n", "/") + "\n"
+		text += describePhrases(phrase.Children, indent+" ")
+	}
+	return text
+}
+
+func TestParseMultilevelPlainText(t *testing.T) {
+	content := "\n  In the beginning.\r\nGod created\n\n\n\tAnd the earth \nwas void.\n"
+	want := `p000001=In the beginning./God created
+ p000001s000001=In the beginning.
+  p000001s000001w000001=In
+  p000001s000001w000002=the
+  p000001s000001w000003=beginning.
+ p000001s000002=God created
+  p000001s000002w000001=God
+  p000001s000002w000002=created
+p000002=And the earth/was void.
+ p000002s000001=And the earth
+  p000002s000001w000001=And
+  p000002s000001w000002=the
+  p000002s000001w000003=earth
+ p000002s000002=was void.
+  p000002s000002w000001=was
+  p000002s000002w000002=void.
+`
+	if got := describePhrases(ParseMultilevelPlainText(content), ""); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if phrases := ParseMultilevelPlainText("\n \n"); len(phrases) != 0 {
+		t.Errorf("blank text: got %d paragraphs", len(phrases))
+	}
+}
@@ -0,0 +1,30 @@
+package datatypes
+
+import (
+	"io"
+	"time"
+)
+
+// Result of an alignment: the fragments of text with the time they are spoken in the audio
+type SyncMap struct {
+	Fragments []*SyncMapFragment
+	// Length of the aligned audio
+	Duration time.Duration
+}
+
+type SyncMapFragment struct {
+	Id    string
+	Begin time.Duration
+	End   time.Duration
+	Text  string
+	// Finer fragments inside this one (sentences of a paragraph, words of a sentence...)
+	Children []*SyncMapFragment
+}
+
+// Writes a sync map in one output format, selected with `os_task_file_format`
+type SyncMapWriter interface {
+	// Checks the task and the parameters read by Write, so that they are rejected before the alignment
+	Check(task *Task, parameters *Parameters) error
+	Write(task *Task, parameters *Parameters, syncMap *SyncMap, output io.Writer) error
+	GetName() string
+}
@@ -12,6 +12,41 @@ type Task struct {
 	PhraseFilename string `json:"phraseFilename"`
 	Parameters     string `json:"parameters"`
 	OutputFilename string `json:"outputFilename"`
+	// USFM book code (GEN, MAT...) and chapter of the recording, used by the timing file
+	Book    string `json:"book"`
+	Chapter int    `json:"chapter"`
+	// Unit of text of each phrase: phrase (default), sentence or verse
+	Level string `json:"level"`
+}
+
+const (
+	LevelPhrase   = "phrase"
+	LevelSentence = "sentence"
+	LevelVerse    = "verse"
+)
+
+// Checks the scripture metadata of the task, the fields which are not set are not checked
+func (task *Task) Validate() error {
+	if task.Book != "" && !IsUsfmBook(task.Book) {
+		return fmt.Errorf("book %q is not a USFM book code", task.Book)
+	}
+	if task.Chapter < 0 {
+		return fmt.Errorf("chapter %d is negative", task.Chapter)
+	}
+	switch task.Level {
+	case "", LevelPhrase, LevelSentence, LevelVerse:
+	default:
+		return fmt.Errorf("level %q is not one of %s, %s or %s", task.Level, LevelPhrase, LevelSentence, LevelVerse)
+	}
+	return nil
+}
+
+// Level of the task, LevelPhrase when it is not set
+func (task *Task) GetLevel() string {
+	if task.Level == "" {
+		return LevelPhrase
+	}
+	return task.Level
 }
 
 type TaskProcessVariables struct {
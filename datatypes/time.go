@@ -0,0 +1,44 @@
+package datatypes
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Number of decimals of the times written to the output (milliseconds)
+const DefaultTimePrecision = 3
+
+// Reads `os_task_file_time_precision`, the number of decimals of the times written to
+// the output, from 0 (whole seconds) to 9 (nanoseconds)
+func (p Parameters) GetTimePrecision() (int, error) {
+	precision, err := p.GetInt("os_task_file_time_precision", DefaultTimePrecision)
+	if err != nil {
+		return 0, err
+	}
+	if precision < 0 || precision > 9 {
+		return 0, fmt.Errorf("parameter os_task_file_time_precision: %d is not between 0 and 9", precision)
+	}
+	return precision, nil
+}
+
+// Formats a time as decimal seconds, rounded to precision decimals
+func FormatSeconds(t time.Duration, precision int) string {
+	rounded := t.Round(time.Duration(math.Pow10(9 - precision)))
+	return strconv.FormatFloat(rounded.Seconds(), 'f', precision, 64)
+}
+
+// Formats a time as hh:mm:ss.sss, rounded to precision decimals
+func FormatClock(t time.Duration, precision int) string {
+	rounded := t.Round(time.Duration(math.Pow10(9 - precision)))
+	hours := rounded / time.Hour
+	minutes := rounded % time.Hour / time.Minute
+	seconds := (rounded % time.Minute).Seconds()
+
+	width := 2
+	if precision > 0 {
+		width += precision + 1
+	}
+	return fmt.Sprintf("%02d:%02d:%0*.*f", hours, minutes, width, precision, seconds)
+}
@@ -0,0 +1,70 @@
+package datatypes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatSeconds(t *testing.T) {
+	tests := []struct {
+		t         time.Duration
+		precision int
+		want      string
+	}{
+		{0, 3, "0.000"},
+		{1234567 * time.Microsecond, 3, "1.235"},
+		{1234567 * time.Microsecond, 6, "1.234567"},
+		{1234567 * time.Microsecond, 0, "1"},
+		{1500 * time.Millisecond, 0, "2"},
+		{999999 * time.Microsecond, 3, "1.000"},
+		{1234567891 * time.Nanosecond, 9, "1.234567891"},
+		{2*time.Hour + 5*time.Second, 2, "7205.00"},
+	}
+	for _, test := range tests {
+		if got := FormatSeconds(test.t, test.precision); got != test.want {
+			t.Errorf("%v at %d: got %s, want %s", test.t, test.precision, got, test.want)
+		}
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	tests := []struct {
+		t         time.Duration
+		precision int
+		want      string
+	}{
+		{0, 3, "00:00:00.000"},
+		{1234567 * time.Microsecond, 3, "00:00:01.235"},
+		{1234567 * time.Microsecond, 0, "00:00:01"},
+		{59999600 * time.Microsecond, 3, "00:01:00.000"},
+		{59999600 * time.Microsecond, 0, "00:01:00"},
+		{time.Hour - 100*time.Microsecond, 3, "01:00:00.000"},
+		{61*time.Minute + 2500*time.Millisecond, 1, "01:01:02.5"},
+		{100*time.Hour + 5*time.Millisecond, 3, "100:00:00.005"},
+	}
+	for _, test := range tests {
+		if got := FormatClock(test.t, test.precision); got != test.want {
+			t.Errorf("%v at %d: got %s, want %s", test.t, test.precision, got, test.want)
+		}
+	}
+}
+
+func TestGetTimePrecision(t *testing.T) {
+	for _, test := range []struct {
+		parameters string
+		want       int
+		valid      bool
+	}{
+		{"task_language=eng", DefaultTimePrecision, true},
+		{"os_task_file_time_precision=0", 0, true},
+		{"os_task_file_time_precision=9", 9, true},
+		{"os_task_file_time_precision=10", 0, false},
+		{"os_task_file_time_precision=-1", 0, false},
+		{"os_task_file_time_precision=ms", 0, false},
+	} {
+		precision, err := ParseParameters(test.parameters).GetTimePrecision()
+		if (err == nil) != test.valid || precision != test.want {
+			t.Errorf("%s: got %d, %v", test.parameters, precision, err)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package datatypes
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Orders of the phrases read from XHTML, selected with `is_text_unparsed_id_sort`
+const (
+	// By the number made of the digits of the id (f2 before f10)
+	SortNumeric = "numeric"
+	// By id (f10 before f2)
+	SortLexicographic = "lexicographic"
+	// In the order of the document
+	SortUnsorted = "unsorted"
+)
+
+// Elements which separate words, unlike inline elements which may be inside a word
+var xhtmlBreakElements = map[string]bool{
+	"br": true, "hr": true, "p": true, "div": true, "li": true, "blockquote": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"td": true, "th": true, "tr": true, "section": true, "article": true,
+}
+
+// Element of an XHTML document, only keeping what is needed to select phrases
+type xhtmlElement struct {
+	id      string
+	classes []string
+	text    strings.Builder
+	// Child elements, in the order of the document
+	children []*xhtmlElement
+}
+
+// Reads the elements of an XHTML (or HTML) document
+//
+// The parser is lenient, so unclosed elements and HTML entities do not stop it.
+func parseXhtml(content io.Reader) (*xhtmlElement, error) {
+	decoder := xml.NewDecoder(content)
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	root := &xhtmlElement{}
+	stack := []*xhtmlElement{root}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading XHTML: %w", err)
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			if xhtmlBreakElements[strings.ToLower(token.Name.Local)] {
+				writeText(stack, " ")
+			}
+			element := &xhtmlElement{}
+			for _, attribute := range token.Attr {
+				switch attribute.Name.Local {
+				case "id":
+					element.id = attribute.Value
+				case "class":
+					element.classes = strings.Fields(attribute.Value)
+				}
+			}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, element)
+			stack = append(stack, element)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			if xhtmlBreakElements[strings.ToLower(token.Name.Local)] {
+				writeText(stack, " ")
+			}
+		case xml.CharData:
+			writeText(stack, string(token))
+		}
+	}
+	return root, nil
+}
+
+// The text of an element includes the text of its descendants
+func writeText(stack []*xhtmlElement, text string) {
+	for _, element := range stack {
+		element.text.WriteString(text)
+	}
+}
+
+// Outermost descendants of the element which match, in the order of the document
+//
+// Matching elements nested in a matching element are part of its text and are not selected.
+func (element *xhtmlElement) selectElements(match func(element *xhtmlElement) bool) []*xhtmlElement {
+	selected := make([]*xhtmlElement, 0)
+	for _, child := range element.children {
+		if match(child) {
+			selected = append(selected, child)
+		} else {
+			selected = append(selected, child.selectElements(match)...)
+		}
+	}
+	return selected
+}
+
+// Compiles a regular expression which, like in aeneas, has to match the start of the value
+func compileUnparsedRegex(key string, expression string) (*regexp.Regexp, error) {
+	if expression == "" {
+		return nil, nil
+	}
+	compiled, err := regexp.Compile("^(?:" + expression + ")")
+	if err != nil {
+		return nil, fmt.Errorf("parameter %s: %w", key, err)
+	}
+	return compiled, nil
+}
+
+func checkSortOrder(sortOrder string) error {
+	switch sortOrder {
+	case "", SortNumeric, SortLexicographic, SortUnsorted:
+		return nil
+	}
+	return fmt.Errorf("parameter is_text_unparsed_id_sort: unknown sort order %q", sortOrder)
+}
+
+// Phrases of the elements, with the element id as phrase index
+func elementPhrases(elements []*xhtmlElement) ([]*Phrase, error) {
+	phrases := make([]*Phrase, 0, len(elements))
+	for _, element := range elements {
+		text := strings.Join(strings.Fields(element.text.String()), " ")
+		if element.id == "" {
+			return nil, fmt.Errorf("selected element without id: %q", text)
+		}
+		// There would be nothing to synthesize and align
+		if text == "" {
+			return nil, fmt.Errorf("selected element %q has no text", element.id)
+		}
+		phrases = append(phrases, &Phrase{
+			PhraseIndex: element.id,
+			PhraseText:  text,
+		})
+	}
+	return phrases, nil
+}
+
+// Sorts the phrases in place by their index
+func sortPhrases(phrases []*Phrase, sortOrder string) []*Phrase {
+	switch sortOrder {
+	case SortNumeric:
+		sort.SliceStable(phrases, func(i, j int) bool {
+			return idNumber(phrases[i].PhraseIndex) < idNumber(phrases[j].PhraseIndex)
+		})
+	case SortLexicographic:
+		sort.SliceStable(phrases, func(i, j int) bool {
+			return phrases[i].PhraseIndex < phrases[j].PhraseIndex
+		})
+	}
+	return phrases
+}
+
+// Number made of the digits of an id, 0 when it has none
+func idNumber(id string) int {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, id)
+	number, _ := strconv.Atoi(digits)
+	return number
+}
+
+// Phrases of the elements of an XHTML document selected by `is_text_unparsed_id_regex` and/or
+// `is_text_unparsed_class_regex` (an element matches when its id, or one of its classes,
+// starts with a match), ordered by `is_text_unparsed_id_sort` (unsorted by default)
+func ParseUnparsedText(content io.Reader, parameters *Parameters) ([]*Phrase, error) {
+	idRegex, err := compileUnparsedRegex("is_text_unparsed_id_regex", parameters.Get("is_text_unparsed_id_regex"))
+	if err != nil {
+		return nil, err
+	}
+	classRegex, err := compileUnparsedRegex("is_text_unparsed_class_regex", parameters.Get("is_text_unparsed_class_regex"))
+	if err != nil {
+		return nil, err
+	}
+	if idRegex == nil && classRegex == nil {
+		return nil, fmt.Errorf("the unparsed text type requires is_text_unparsed_id_regex or is_text_unparsed_class_regex")
+	}
+	sortOrder := parameters.Get("is_text_unparsed_id_sort")
+	if err := checkSortOrder(sortOrder); err != nil {
+		return nil, err
+	}
+
+	root, err := parseXhtml(content)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := root.selectElements(func(element *xhtmlElement) bool {
+		if idRegex != nil && !idRegex.MatchString(element.id) {
+			return false
+		}
+		if classRegex != nil {
+			for _, class := range element.classes {
+				if classRegex.MatchString(class) {
+					return true
+				}
+			}
+			return false
+		}
+		return true
+	})
+	phrases, err := elementPhrases(elements)
+	if err != nil {
+		return nil, err
+	}
+	return sortPhrases(phrases, sortOrder), nil
+}
+
+// Phrases of the elements of an XHTML document whose id matches `is_text_munparsed_l1_id_regex`,
+// each with the elements inside it matching `is_text_munparsed_l2_id_regex` as children, which
+// have the ones matching `is_text_munparsed_l3_id_regex` as children. Levels without a regex
+// are left out. Each level is ordered by `is_text_unparsed_id_sort`.
+func ParseMultilevelUnparsedText(content io.Reader, parameters *Parameters) ([]*Phrase, error) {
+	levelRegexes := make([]*regexp.Regexp, 0)
+	for level := 1; level <= 3; level++ {
+		key := fmt.Sprintf("is_text_munparsed_l%d_id_regex", level)
+		regex, err := compileUnparsedRegex(key, parameters.Get(key))
+		if err != nil {
+			return nil, err
+		}
+		if regex == nil {
+			break
+		}
+		levelRegexes = append(levelRegexes, regex)
+	}
+	if len(levelRegexes) == 0 {
+		return nil, fmt.Errorf("the munparsed text type requires is_text_munparsed_l1_id_regex")
+	}
+	sortOrder := parameters.Get("is_text_unparsed_id_sort")
+	if err := checkSortOrder(sortOrder); err != nil {
+		return nil, err
+	}
+
+	root, err := parseXhtml(content)
+	if err != nil {
+		return nil, err
+	}
+	return levelPhrases(root, levelRegexes, sortOrder)
+}
+
+func levelPhrases(parent *xhtmlElement, levelRegexes []*regexp.Regexp, sortOrder string) ([]*Phrase, error) {
+	elements := parent.selectElements(func(element *xhtmlElement) bool {
+		return levelRegexes[0].MatchString(element.id)
+	})
+	phrases, err := elementPhrases(elements)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(levelRegexes) > 1 {
+		for i, element := range elements {
+			if phrases[i].Children, err = levelPhrases(element, levelRegexes[1:], sortOrder); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Sorted once the children are attached, elements and phrases being in step until then
+	return sortPhrases(phrases, sortOrder), nil
+}
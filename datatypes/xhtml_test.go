@@ -0,0 +1,116 @@
+package datatypes
+
+import (
+	"strings"
+	"testing"
+)
+
+const testXhtml = `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml">
+ <body>
+  <p id="p10" class="verse poetry"><span id="p10s2">Second&nbsp;line</span> <span id="p10s1">First <em>line</em></span></p>
+  <p id="p2" class="verse"><span id="p2s1">Only</span><br/><span>unselected</span></p>
+  <p id="note1" class="note">A note</p>
+  <div id="p3">Outer<p id="p3a">inner</p></div>
+ </body>
+</html>`
+
+func TestParseMultilevelUnparsedText(t *testing.T) {
+	tests := []struct {
+		parameters string
+		want       string
+	}{
+		{"is_text_munparsed_l1_id_regex=p[0-9]+$|is_text_munparsed_l2_id_regex=p[0-9]+s", `p10=Second line First line
+ p10s2=Second line
+ p10s1=First line
+p2=Only unselected
+ p2s1=Only
+p3=Outer inner
+`},
+		{"is_text_munparsed_l1_id_regex=p[0-9]+$|is_text_munparsed_l2_id_regex=p[0-9]+s|is_text_unparsed_id_sort=numeric", `p2=Only unselected
+ p2s1=Only
+p3=Outer inner
+p10=Second line First line
+ p10s1=First line
+ p10s2=Second line
+`},
+		// Without a level 2 regex, level 3 is left out
+		{"is_text_munparsed_l1_id_regex=note|is_text_munparsed_l3_id_regex=p", "note1=A note\n"},
+	}
+	for _, test := range tests {
+		phrases, err := ParseMultilevelUnparsedText(strings.NewReader(testXhtml), ParseParameters(test.parameters))
+		if err != nil {
+			t.Errorf("%s: %v", test.parameters, err)
+			continue
+		}
+		if got := describePhrases(phrases, ""); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.parameters, got, test.want)
+		}
+	}
+
+	for _, parameters := range []string{
+		"is_text_munparsed_l2_id_regex=p",
+		"is_text_munparsed_l1_id_regex=p(",
+		"is_text_munparsed_l1_id_regex=p|is_text_unparsed_id_sort=random",
+	} {
+		if _, err := ParseMultilevelUnparsedText(strings.NewReader(testXhtml), ParseParameters(parameters)); err == nil {
+			t.Errorf("%s: no error", parameters)
+		}
+	}
+}
+
+func TestParseUnparsedText(t *testing.T) {
+	tests := []struct {
+		name       string
+		parameters string
+		want       string
+	}{
+		{"id", "is_text_unparsed_id_regex=p[0-9]+s", "p10s2=Second line\np10s1=First line\np2s1=Only\n"},
+		{"id matching the start", "is_text_unparsed_id_regex=p1", "p10=Second line First line\n"},
+		{"class", "is_text_unparsed_class_regex=verse", "p10=Second line First line\np2=Only unselected\n"},
+		{"one of the classes", "is_text_unparsed_class_regex=poe", "p10=Second line First line\n"},
+		{"id and class", "is_text_unparsed_id_regex=p2|is_text_unparsed_class_regex=verse", "p2=Only unselected\n"},
+		{"nested matches are part of the outer one", "is_text_unparsed_id_regex=p3", "p3=Outer inner\n"},
+		{"numeric", "is_text_unparsed_id_regex=p[0-9]+s|is_text_unparsed_id_sort=numeric", "p2s1=Only\np10s1=First line\np10s2=Second line\n"},
+		{"lexicographic", "is_text_unparsed_id_regex=p[0-9]+s|is_text_unparsed_id_sort=lexicographic", "p10s1=First line\np10s2=Second line\np2s1=Only\n"},
+		{"unsorted", "is_text_unparsed_id_regex=p[0-9]+s|is_text_unparsed_id_sort=unsorted", "p10s2=Second line\np10s1=First line\np2s1=Only\n"},
+	}
+	for _, test := range tests {
+		phrases, err := ParseUnparsedText(strings.NewReader(testXhtml), ParseParameters(test.parameters))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got := describePhrases(phrases, ""); got != test.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseUnparsedTextErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		parameters string
+	}{
+		{"no regex", testXhtml, "is_text_unparsed_id_sort=numeric"},
+		{"invalid id regex", testXhtml, "is_text_unparsed_id_regex=p["},
+		{"invalid class regex", testXhtml, "is_text_unparsed_class_regex=(verse"},
+		{"unknown sort order", testXhtml, "is_text_unparsed_id_regex=p|is_text_unparsed_id_sort=alphabetical"},
+		{"selected element without id", `<p><span class="v">text</span></p>`, "is_text_unparsed_class_regex=v"},
+		{"selected element without text", `<p><span id="v1"> </span></p>`, "is_text_unparsed_id_regex=v"},
+	}
+	for _, test := range tests {
+		if _, err := ParseUnparsedText(strings.NewReader(test.content), ParseParameters(test.parameters)); err == nil {
+			t.Errorf("%s: no error", test.name)
+		}
+	}
+}
+
+func TestIdNumber(t *testing.T) {
+	for id, want := range map[string]int{"f000010": 10, "p2s13": 213, "intro": 0} {
+		if got := idNumber(id); got != want {
+			t.Errorf("%s: got %d, want %d", id, got, want)
+		}
+	}
+}
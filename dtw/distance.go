@@ -0,0 +1,48 @@
+package dtw
+
+import (
+	"fmt"
+	"math"
+)
+
+// DistanceFunc computes the local cost between two MFCC frames
+type DistanceFunc func(frame1 []float64, frame2 []float64) float64
+
+// EuclideanDistance is the straight line distance between two frames
+func EuclideanDistance(frame1 []float64, frame2 []float64) float64 {
+	sum := 0.0
+	for i := 0; i < len(frame1) && i < len(frame2); i++ {
+		diff := frame1[i] - frame2[i]
+		sum += diff * diff
+	}
+	return math.Sqrt(sum)
+}
+
+// CosineDistance is 1 minus the cosine similarity of two frames, so identical
+// directions cost 0 and opposite directions cost 2
+func CosineDistance(frame1 []float64, frame2 []float64) float64 {
+	dot, norm1, norm2 := 0.0, 0.0, 0.0
+	for i := 0; i < len(frame1) && i < len(frame2); i++ {
+		dot += frame1[i] * frame2[i]
+		norm1 += frame1[i] * frame1[i]
+		norm2 += frame2[i] * frame2[i]
+	}
+
+	if norm1 == 0 || norm2 == 0 {
+		// A silent (all zero) frame has no direction, treat it as unrelated
+		return 1
+	}
+
+	return 1 - dot/(math.Sqrt(norm1)*math.Sqrt(norm2))
+}
+
+// Looks up a distance function by the name used in the task parameters (`dtw_distance`)
+func GetDistanceFunc(name string) (DistanceFunc, error) {
+	switch name {
+	case "", "euclidean":
+		return EuclideanDistance, nil
+	case "cosine":
+		return CosineDistance, nil
+	}
+	return nil, fmt.Errorf("unknown dtw distance %q", name)
+}
@@ -2,26 +2,342 @@ package dtw
 
 import (
 	"fmt"
+	"math"
+)
+
+// How much of sequence1 (relative to the length of sequence2) is searched for a match
+const searchWindowFactor = 2
+
+type Algorithm string
+
+const (
+	// Full cost matrix, memory and time grow with the product of both lengths
+	AlgorithmExact Algorithm = "exact"
+	// Sakoe-Chiba band around the diagonal, memory and time grow linearly with the length
+	AlgorithmStripe Algorithm = "stripe"
+	// Multi-resolution approximation, memory and time grow linearly with the length
+	AlgorithmFastDtw Algorithm = "fastdtw"
+)
+
+// Looks up an algorithm by the name used in the task parameters (`dtw_algorithm`)
+func GetAlgorithm(name string) (Algorithm, error) {
+	switch Algorithm(name) {
+	case "":
+		return AlgorithmStripe, nil
+	case AlgorithmExact, AlgorithmStripe, AlgorithmFastDtw:
+		return Algorithm(name), nil
+	}
+	return "", fmt.Errorf("unknown dtw algorithm %q", name)
+}
+
+// Default half width of the stripe in frames: the 60 seconds of aeneas at its 40 ms frame shift.
+// The stripe stores one byte per cell, so aligning n frames takes about n*(2*DefaultMargin+1) bytes.
+const DefaultMargin = 1500
+
+type Options struct {
+	Distance  DistanceFunc
+	Algorithm Algorithm
+	// Half width of the stripe in frames, only used by AlgorithmStripe.
+	// The stripe is always widened to at least the slope of the diagonal.
+	Margin int
+	// Number of frames the projected path is widened by at each resolution, only used by AlgorithmFastDtw
+	Radius int
+}
+
+func DefaultOptions() *Options {
+	return &Options{
+		Distance:  EuclideanDistance,
+		Algorithm: AlgorithmStripe,
+		Margin:    DefaultMargin,
+		Radius:    10,
+	}
+}
+
+// Checks the widths of the stripe and of the FastDTW band, which size the cost matrix
+func (options *Options) Validate() error {
+	if options.Margin < 0 {
+		return fmt.Errorf("dtw margin %d is negative", options.Margin)
+	}
+	if options.Radius < 0 {
+		return fmt.Errorf("dtw radius %d is negative", options.Radius)
+	}
+	return nil
+}
+
+// Part of sequence1 starting at offset where sequence2 is searched for
+func searchWindow(sequence1 [][]float64, sequence2 [][]float64, offset int) [][]float64 {
+	windowEnd := min(offset+searchWindowFactor*len(sequence2), len(sequence1))
+	return sequence1[offset:windowEnd]
+}
+
+// Aligns the whole of sequence2 against the whole of sequence1 (both ends anchored)
+//
+// sequence2Anchors are increasing frame indexes of sequence2, typically where each phrase
+// starts in the concatenated synthesized audio. The result has one segment per anchor.
+func RunGlobalDtw(sequence1 [][]float64, sequence2 [][]float64, sequence2Anchors []int, options *Options) *Result {
+	if len(sequence1) == 0 || len(sequence2) == 0 {
+		return newResult(nil, sequence2Anchors)
+	}
+
+	return newResult(align(sequence1, sequence2, false, false, options), sequence2Anchors)
+}
+
+// Computes the warping path of sequence2 against sequence1 with the algorithm of the options
+//
+// The path starts at the first frame of both sequences, or with openBegin, at the first frame
+// of sequence2 matched with any frame of sequence1. It ends at the last frame of both, or with
+// openEnd, at the last frame of sequence2 matched with any frame of sequence1.
+func align(sequence1 [][]float64, sequence2 [][]float64, openBegin bool, openEnd bool, options *Options) []PathPoint {
+	var band []columnRange
+	switch options.Algorithm {
+	case AlgorithmFastDtw:
+		return fastDtw(sequence1, sequence2, openBegin, openEnd, options)
+	case AlgorithmStripe:
+		diagonalColumns := len(sequence1)
+		if openEnd {
+			// The end is unknown, assume both sequences go at about the same speed
+			diagonalColumns = min(len(sequence2), len(sequence1))
+		}
+		band = stripeBand(len(sequence1), len(sequence2), diagonalColumns, options.Margin)
+	default:
+		band = fullBand(len(sequence1), len(sequence2))
+	}
+	return alignInBand(sequence1, sequence2, band, openBegin, openEnd, options.Distance)
+}
+
+// Computes the warping path only looking at the cells of the band
+func alignInBand(sequence1 [][]float64, sequence2 [][]float64, band []columnRange, openBegin bool, openEnd bool, distance DistanceFunc) []PathPoint {
+	matrix := newCostMatrix(sequence1, sequence2, band, distance)
+	matrix.openBegin = openBegin
+	matrix.weighted = openBegin || openEnd
+	matrix.accumulate()
+
+	last := len(sequence2) - 1
+	if !openEnd {
+		return matrix.backtrack(last, len(sequence1)-1)
+	}
+
+	// Open end: pick the column of the last row with the lowest cost per row and column
+	// spanned by the match, otherwise shorter matches would always win
+	bestEnd := 0
+	bestCost := math.Inf(1)
+	for k, cost := range matrix.lastRow {
+		j := matrix.columnStart[last] + k
+		normalized := cost / float64(len(sequence2)+j-matrix.lastBegins[k]+1)
+		if normalized < bestCost {
+			bestCost = normalized
+			bestEnd = j
+		}
+	}
+	return matrix.backtrack(last, bestEnd)
+}
+
+// Columns [start, end) of one row of the cost matrix which are computed
+type columnRange struct {
+	start int
+	end   int
+}
+
+// Band covering every cell of the matrix
+func fullBand(columns int, rows int) []columnRange {
+	band := make([]columnRange, rows)
+	for i := range band {
+		band[i] = columnRange{start: 0, end: columns}
+	}
+	return band
+}
+
+// Band of half width margin around the diagonal going from the first cell of the matrix
+// to the last row at column diagonalColumns-1
+func stripeBand(columns int, rows int, diagonalColumns int, margin int) []columnRange {
+	// The stripe has to be at least as wide as the slope of the diagonal,
+	// otherwise consecutive rows would not overlap and there would be no path
+	slope := int(math.Ceil(float64(diagonalColumns) / float64(rows)))
+	margin = max(margin, slope)
+
+	band := make([]columnRange, rows)
+	for i := range band {
+		center := 0
+		if rows > 1 {
+			center = i * (diagonalColumns - 1) / (rows - 1)
+		}
+		band[i] = columnRange{start: max(center-margin, 0), end: min(center+margin+1, columns)}
+	}
+	return band
+}
 
-	"github.com/r9y9/gossp/dtw"
+// Step taken to reach a cell of the cost matrix from the previous cell of the path
+type step byte
+
+const (
+	// First cell of the path
+	stepNone step = iota
+	stepDiagonal
+	stepUp
+	stepLeft
 )
 
-// sequence1 is template, sequence2 is aligned with sequence1
-func RunDtw(sequence1 [][]float64, sequence2 [][]float64, sequence1Offset int) int {
-	dtwObject := dtw.DTW{ForwardStep: 10, BackwardStep: 10}
+// Cost matrix with one row per frame of sequence2 and one column per frame of sequence1
+//
+// Each row only covers the columns inside the band allowed by the algorithm, so the
+// exact algorithm covers every column, the stripe algorithm 2*margin+1 of them and
+// FastDTW the ones around the path projected from the lower resolution. Only the step
+// taken to reach each cell is kept (one byte per cell), the accumulated costs are kept
+// for two rows at a time and recomputed along the path once it is known.
+type costMatrix struct {
+	sequence1   [][]float64
+	sequence2   [][]float64
+	distance    DistanceFunc
+	columnStart []int
+	steps       [][]step
+	// Accumulated costs of the last row and the column where the path to each cell starts,
+	// set by accumulate
+	lastRow    []float64
+	lastBegins []int
+	// sequence2 may start at any column of the first row instead of only the first one
+	openBegin bool
+	// Diagonal steps and the first cell count twice, so that the accumulated cost of every
+	// path is spread over as many weights as the rows and columns it spans, and matches
+	// of different lengths can be compared once divided by that number
+	weighted bool
+}
+
+func newCostMatrix(sequence1 [][]float64, sequence2 [][]float64, band []columnRange, distance DistanceFunc) *costMatrix {
+	matrix := &costMatrix{
+		sequence1:   sequence1,
+		sequence2:   sequence2,
+		distance:    distance,
+		columnStart: make([]int, len(sequence2)),
+		steps:       make([][]step, len(sequence2)),
+	}
+
+	for i := range sequence2 {
+		matrix.columnStart[i] = band[i].start
+		matrix.steps[i] = make([]step, band[i].end-band[i].start)
+	}
+
+	return matrix
+}
+
+// Computes the cheapest cost of reaching each cell from (0, 0), or from any cell of the first
+// row with openBegin, using horizontal, vertical and diagonal steps, and records the step taken
+//
+// When weighted, the cost of reaching a cell is compared per weight, the rows and columns spanned
+// since the start of the path, so that with openBegin a path starting later is not preferred
+// only because it is shorter.
+func (matrix *costMatrix) accumulate() {
+	diagonalWeight := 1.0
+	if matrix.weighted {
+		diagonalWeight = 2
+	}
+
+	var previous, row []float64
+	var previousBegins, begins []int
+	previousStart := 0
+	// Accumulated cost of column j of the previous row and the column where its path starts,
+	// +Inf outside its band
+	previousCell := func(j int) (float64, int) {
+		if previous == nil || j < previousStart || j >= previousStart+len(previous) {
+			return math.Inf(1), 0
+		}
+		return previous[j-previousStart], previousBegins[j-previousStart]
+	}
+
+	for i, steps := range matrix.steps {
+		row = make([]float64, len(steps))
+		begins = make([]int, len(steps))
+		// Cost to compare of reaching column j of this row with an accumulated cost from begin
+		score := func(j int, cost float64, begin int) float64 {
+			if !matrix.weighted {
+				return cost
+			}
+			return cost / float64(i+1+j-begin+1)
+		}
+
+		for k := range steps {
+			j := matrix.columnStart[i] + k
+			cost := matrix.distance(matrix.sequence1[j], matrix.sequence2[i])
+			if i == 0 && (j == 0 || matrix.openBegin) {
+				row[k], begins[k] = diagonalWeight*cost, j
+				// With openBegin, the path may also come from the left along the first row
+				if k > 0 && score(j, row[k-1]+cost, begins[k-1]) < score(j, row[k], j) {
+					row[k], begins[k] = row[k-1]+cost, begins[k-1]
+					steps[k] = stepLeft
+				}
+				continue
+			}
 
-	if false {
-		// Big issue here:
-		// the following lines crash. We don't know why as the signal processing
-		// library doesn't provide documentation on the shape of data
-		dtwObject.SetTemplate(sequence1)
-		path := dtwObject.DTW(sequence2)
+			// Same preference as the backtracking of the path: diagonal, then up, then left
+			diagonal, diagonalBegin := previousCell(j - 1)
+			up, upBegin := previousCell(j)
+			left, leftBegin := math.Inf(1), 0
+			if k > 0 {
+				left, leftBegin = row[k-1], begins[k-1]
+			}
+			diagonal += diagonalWeight * cost
+			up += cost
+			left += cost
+			diagonalScore, upScore, leftScore := score(j, diagonal, diagonalBegin), score(j, up, upBegin), score(j, left, leftBegin)
+			switch {
+			case diagonalScore <= upScore && diagonalScore <= leftScore:
+				row[k], begins[k] = diagonal, diagonalBegin
+				steps[k] = stepDiagonal
+			case upScore <= leftScore:
+				row[k], begins[k] = up, upBegin
+				steps[k] = stepUp
+			default:
+				row[k], begins[k] = left, leftBegin
+				steps[k] = stepLeft
+			}
+		}
+		previous, previousBegins, previousStart = row, begins, matrix.columnStart[i]
+	}
+	matrix.lastRow, matrix.lastBegins = row, begins
+}
+
+// Walks back from (endRow, endColumn) to (0, 0), or to the start of the path in the first row with openBegin,
+// following the recorded steps, returning the path in forward order with its accumulated costs
+func (matrix *costMatrix) backtrack(endRow int, endColumn int) []PathPoint {
+	i, j := endRow, endColumn
+	path := []PathPoint{{Sequence1Index: j, Sequence2Index: i}}
+
+	for i > 0 || (j > 0 && (!matrix.openBegin || matrix.step(i, j) == stepLeft)) {
+		switch {
+		case i == 0:
+			j--
+		case j == 0:
+			i--
+		default:
+			switch matrix.step(i, j) {
+			case stepUp:
+				i--
+			case stepLeft:
+				j--
+			default:
+				i--
+				j--
+			}
+		}
+		path = append(path, PathPoint{Sequence1Index: j, Sequence2Index: i})
+	}
 
-		fmt.Println("Path ", path)
+	for left, right := 0, len(path)-1; left < right; left, right = left+1, right-1 {
+		path[left], path[right] = path[right], path[left]
+	}
 
-		finalIndex := path[len(path)-1]
-		return sequence1Offset + finalIndex
+	cost := 0.0
+	for k := range path {
+		cost += matrix.distance(matrix.sequence1[path[k].Sequence1Index], matrix.sequence2[path[k].Sequence2Index])
+		path[k].Cost = cost
 	}
+	return path
+}
 
-	return sequence1Offset
+// Step recorded for row i, column j, diagonal when the cell lies outside the band
+func (matrix *costMatrix) step(i int, j int) step {
+	k := j - matrix.columnStart[i]
+	if k < 0 || k >= len(matrix.steps[i]) {
+		return stepDiagonal
+	}
+	return matrix.steps[i][k]
 }
@@ -0,0 +1,351 @@
+package dtw
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomSequence(random *rand.Rand, length int) [][]float64 {
+	sequence := make([][]float64, length)
+	for i := range sequence {
+		sequence[i] = []float64{random.Float64(), random.Float64(), random.Float64()}
+	}
+	return sequence
+}
+
+// Cost of the cheapest global path, computed over the whole matrix without any optimization
+func referenceCost(sequence1 [][]float64, sequence2 [][]float64) float64 {
+	costs := make([][]float64, len(sequence2))
+	for i := range costs {
+		costs[i] = make([]float64, len(sequence1))
+		for j := range costs[i] {
+			previous := math.Inf(1)
+			switch {
+			case i == 0 && j == 0:
+				previous = 0
+			case i == 0:
+				previous = costs[i][j-1]
+			case j == 0:
+				previous = costs[i-1][j]
+			default:
+				previous = math.Min(costs[i-1][j-1], math.Min(costs[i-1][j], costs[i][j-1]))
+			}
+			costs[i][j] = previous + EuclideanDistance(sequence1[j], sequence2[i])
+		}
+	}
+	return costs[len(sequence2)-1][len(sequence1)-1]
+}
+
+// Fails unless the path goes from the first to the last frames of both sequences,
+// only takes single steps forward and its costs add up the local costs
+func checkPath(t *testing.T, path []PathPoint, sequence1 [][]float64, sequence2 [][]float64) {
+	t.Helper()
+	first, last := path[0], path[len(path)-1]
+	if first.Sequence1Index != 0 || first.Sequence2Index != 0 ||
+		last.Sequence1Index != len(sequence1)-1 || last.Sequence2Index != len(sequence2)-1 {
+		t.Fatalf("path goes from %+v to %+v", first, last)
+	}
+	cost := 0.0
+	for k, point := range path {
+		if k > 0 {
+			step1 := point.Sequence1Index - path[k-1].Sequence1Index
+			step2 := point.Sequence2Index - path[k-1].Sequence2Index
+			if step1 < 0 || step1 > 1 || step2 < 0 || step2 > 1 || step1+step2 == 0 {
+				t.Fatalf("invalid step from %+v to %+v", path[k-1], point)
+			}
+		}
+		cost += EuclideanDistance(sequence1[point.Sequence1Index], sequence2[point.Sequence2Index])
+		if math.Abs(point.Cost-cost) > 1e-9 {
+			t.Fatalf("cost of %+v should be %v", point, cost)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		margin int
+		radius int
+		valid  bool
+	}{
+		{"default", DefaultMargin, 10, true},
+		{"zero widths", 0, 0, true},
+		{"negative margin", -1, 10, false},
+		{"negative radius", DefaultMargin, -1, false},
+	}
+	for _, test := range tests {
+		options := DefaultOptions()
+		options.Margin, options.Radius = test.margin, test.radius
+		if err := options.Validate(); (err == nil) != test.valid {
+			t.Errorf("%s: got %v", test.name, err)
+		}
+	}
+}
+
+func TestFastDtw(t *testing.T) {
+	random := rand.New(rand.NewSource(2))
+	for _, radius := range []int{0, 1, 10} {
+		for run := 0; run < 10; run++ {
+			sequence1 := randomSequence(random, 20+random.Intn(100))
+			sequence2 := randomSequence(random, 20+random.Intn(100))
+			options := DefaultOptions()
+			options.Algorithm = AlgorithmFastDtw
+			options.Radius = radius
+			result := RunGlobalDtw(sequence1, sequence2, []int{0}, options)
+			checkPath(t, result.Path, sequence1, sequence2)
+
+			// An approximation, never cheaper than the optimum
+			if want := referenceCost(sequence1, sequence2); result.Cost < want-1e-9 {
+				t.Errorf("radius %d: cost %v below the optimum %v", radius, result.Cost, want)
+			}
+		}
+	}
+}
+
+func TestFastDtwIdentical(t *testing.T) {
+	sequence := randomSequence(rand.New(rand.NewSource(1)), 100)
+	options := DefaultOptions()
+	options.Algorithm = AlgorithmFastDtw
+	result := RunGlobalDtw(sequence, sequence, []int{0}, options)
+	if result.Cost != 0 || len(result.Path) != len(sequence) {
+		t.Errorf("cost %v over %d points, want a diagonal of cost 0", result.Cost, len(result.Path))
+	}
+}
+
+func TestDistances(t *testing.T) {
+	tests := []struct {
+		name     string
+		distance DistanceFunc
+		frame1   []float64
+		frame2   []float64
+		want     float64
+	}{
+		{"euclidean", EuclideanDistance, []float64{0, 0}, []float64{3, 4}, 5},
+		{"euclidean identical", EuclideanDistance, []float64{1, 2}, []float64{1, 2}, 0},
+		{"cosine identical direction", CosineDistance, []float64{1, 2}, []float64{2, 4}, 0},
+		{"cosine orthogonal", CosineDistance, []float64{1, 0}, []float64{0, 1}, 1},
+		{"cosine opposite", CosineDistance, []float64{1, 1}, []float64{-1, -1}, 2},
+		{"cosine silent frame", CosineDistance, []float64{0, 0}, []float64{1, 1}, 1},
+	}
+	for _, test := range tests {
+		if got := test.distance(test.frame1, test.frame2); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+	if _, err := GetDistanceFunc("manhattan"); err == nil {
+		t.Error("unknown distance accepted")
+	}
+}
+
+func TestRunGlobalDtwExact(t *testing.T) {
+	random := rand.New(rand.NewSource(3))
+	options := DefaultOptions()
+	options.Algorithm = AlgorithmExact
+
+	sequence := randomSequence(random, 50)
+	result := RunGlobalDtw(sequence, sequence, []int{0}, options)
+	if result.Cost != 0 || len(result.Path) != len(sequence) {
+		t.Errorf("identical sequences: cost %v over %d points, want a diagonal of cost 0", result.Cost, len(result.Path))
+	}
+
+	for run := 0; run < 20; run++ {
+		sequence1 := randomSequence(random, 1+random.Intn(80))
+		sequence2 := randomSequence(random, 1+random.Intn(80))
+		result := RunGlobalDtw(sequence1, sequence2, []int{0}, options)
+		checkPath(t, result.Path, sequence1, sequence2)
+		if want := referenceCost(sequence1, sequence2); math.Abs(result.Cost-want) > 1e-9 {
+			t.Errorf("%dx%d: cost %v, want %v", len(sequence1), len(sequence2), result.Cost, want)
+		}
+	}
+}
+
+func TestGetAlgorithm(t *testing.T) {
+	if algorithm, err := GetAlgorithm(""); err != nil || algorithm != AlgorithmStripe {
+		t.Errorf("default algorithm: got %q, %v", algorithm, err)
+	}
+	if _, err := GetAlgorithm("dijkstra"); err == nil {
+		t.Error("unknown algorithm accepted")
+	}
+}
+
+// With the default margin the stripe covers the whole matrix of short sequences
+func TestStripe(t *testing.T) {
+	random := rand.New(rand.NewSource(4))
+	for run := 0; run < 20; run++ {
+		sequence1 := randomSequence(random, 20+random.Intn(60))
+		sequence2 := randomSequence(random, 20+random.Intn(60))
+		result := RunGlobalDtw(sequence1, sequence2, []int{0}, DefaultOptions())
+		checkPath(t, result.Path, sequence1, sequence2)
+		if want := referenceCost(sequence1, sequence2); math.Abs(result.Cost-want) > 1e-9 {
+			t.Errorf("cost %v, want %v", result.Cost, want)
+		}
+	}
+}
+
+func TestStripeMargin(t *testing.T) {
+	random := rand.New(rand.NewSource(5))
+	sequence1 := randomSequence(random, 200)
+	sequence2 := randomSequence(random, 100)
+
+	options := DefaultOptions()
+	options.Margin = 5
+	result := RunGlobalDtw(sequence1, sequence2, []int{0}, options)
+	checkPath(t, result.Path, sequence1, sequence2)
+
+	// Margin widened to the slope of the diagonal (2 columns per row)
+	for _, point := range result.Path {
+		center := point.Sequence2Index * (len(sequence1) - 1) / (len(sequence2) - 1)
+		if point.Sequence1Index < center-options.Margin || point.Sequence1Index > center+options.Margin {
+			t.Fatalf("%+v outside the stripe", point)
+		}
+	}
+	if want := referenceCost(sequence1, sequence2); result.Cost < want-1e-9 {
+		t.Errorf("cost %v below the optimum %v", result.Cost, want)
+	}
+}
+
+// Each frame repeated times times, as if read more slowly
+func stretch(sequence [][]float64, times int) [][]float64 {
+	stretched := make([][]float64, 0, len(sequence)*times)
+	for _, frame := range sequence {
+		for k := 0; k < times; k++ {
+			stretched = append(stretched, frame)
+		}
+	}
+	return stretched
+}
+
+func TestRunGlobalDtwSegments(t *testing.T) {
+	random := rand.New(rand.NewSource(6))
+	phrases := [][][]float64{randomSequence(random, 10), randomSequence(random, 20), randomSequence(random, 15)}
+
+	// Synthesized phrases one after another, the recording reads them twice as slowly
+	synthesized := make([][]float64, 0)
+	anchors := make([]int, 0)
+	for _, phrase := range phrases {
+		anchors = append(anchors, len(synthesized))
+		synthesized = append(synthesized, phrase...)
+	}
+	recording := stretch(synthesized, 2)
+
+	for _, algorithm := range []Algorithm{AlgorithmExact, AlgorithmStripe, AlgorithmFastDtw} {
+		options := DefaultOptions()
+		options.Algorithm = algorithm
+		result := RunGlobalDtw(recording, synthesized, anchors, options)
+		if len(result.Segments) != len(anchors) {
+			t.Fatalf("%s: %d segments for %d anchors", algorithm, len(result.Segments), len(anchors))
+		}
+
+		for k, segment := range result.Segments {
+			wantStart := 2 * anchors[k]
+			wantEnd := len(recording)
+			if k+1 < len(anchors) {
+				wantEnd = 2 * anchors[k+1]
+			}
+			if segment.Start != wantStart || segment.End != wantEnd || segment.Distance != 0 {
+				t.Errorf("%s: segment %d is %+v, want [%d, %d)", algorithm, k, segment, wantStart, wantEnd)
+			}
+		}
+	}
+}
+
+func TestRunGlobalDtwEmpty(t *testing.T) {
+	result := RunGlobalDtw(nil, randomSequence(rand.New(rand.NewSource(7)), 5), []int{0, 2}, DefaultOptions())
+	if len(result.Path) != 0 || len(result.Segments) != 2 {
+		t.Errorf("got %+v, want an empty path and one segment per anchor", result)
+	}
+}
+
+// Frames changing slowly like the MFCC of speech, unlike randomSequence whose frames are unrelated
+func smoothSequence(random *rand.Rand, length int) [][]float64 {
+	sequence := make([][]float64, length)
+	frame := []float64{0, 0, 0}
+	for i := range sequence {
+		next := make([]float64, len(frame))
+		for k := range next {
+			next[k] = frame[k] + random.NormFloat64()
+		}
+		sequence[i], frame = next, next
+	}
+	return sequence
+}
+
+// Linear interpolation of the sequence at another length, as if read at another speed,
+// with gaussian noise of the given standard deviation added to every value
+func resample(random *rand.Rand, sequence [][]float64, length int, noise float64) [][]float64 {
+	resampled := make([][]float64, length)
+	for i := range resampled {
+		position := float64(i) * float64(len(sequence)-1) / float64(length-1)
+		j := min(int(position), len(sequence)-2)
+		fraction := position - float64(j)
+		frame := make([]float64, len(sequence[j]))
+		for k := range frame {
+			frame[k] = (1-fraction)*sequence[j][k] + fraction*sequence[j+1][k] + noise*random.NormFloat64()
+		}
+		resampled[i] = frame
+	}
+	return resampled
+}
+
+func TestRunSubsequenceDtw(t *testing.T) {
+	random := rand.New(rand.NewSource(8))
+	recording := randomSequence(random, 2000)
+
+	tests := []struct {
+		name        string
+		start       int
+		end         int
+		searchStart int
+	}{
+		{"at the search start", 300, 340, 300},
+		{"after the search start", 120, 160, 100},
+		{"long phrase", 500, 800, 450},
+		{"at the end of the recording", 1950, 2000, 1940},
+	}
+	for _, test := range tests {
+		for _, algorithm := range []Algorithm{AlgorithmExact, AlgorithmStripe, AlgorithmFastDtw} {
+			options := DefaultOptions()
+			options.Algorithm = algorithm
+			options.Margin = 100
+			phrase := recording[test.start:test.end]
+			result := RunSubsequenceDtw(recording, phrase, test.searchStart, options)
+
+			segment := result.Segments[0]
+			if segment.Start != test.start || segment.End != test.end || result.Cost != 0 {
+				t.Errorf("%s, %s: found %+v with cost %v, want [%d, %d)", test.name, algorithm, segment, result.Cost, test.start, test.end)
+			}
+		}
+	}
+}
+
+// The phrase is read one and a half times as slowly and with noise, so no frame matches exactly
+// and the ends of the match have to be found by the cost per frame, not the total cost
+func TestRunSubsequenceDtwStretched(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		random := rand.New(rand.NewSource(seed))
+		phrase := smoothSequence(random, 60)
+		recording := smoothSequence(random, 30)
+		recording = append(recording, resample(random, phrase, 90, 0.3)...)
+		recording = append(recording, smoothSequence(random, 100)...)
+
+		for _, algorithm := range []Algorithm{AlgorithmExact, AlgorithmStripe, AlgorithmFastDtw} {
+			options := DefaultOptions()
+			options.Algorithm = algorithm
+			result := RunSubsequenceDtw(recording, phrase, 20, options)
+
+			segment := result.Segments[0]
+			if segment.Start < 29 || segment.Start > 31 || segment.End < 119 || segment.End > 121 {
+				t.Errorf("seed %d, %s: found %+v, want about [30, 120)", seed, algorithm, segment)
+			}
+		}
+	}
+}
+
+func TestRunSubsequenceDtwPastTheEnd(t *testing.T) {
+	sequence := randomSequence(rand.New(rand.NewSource(9)), 10)
+	result := RunSubsequenceDtw(sequence, sequence, len(sequence), DefaultOptions())
+	if segment := result.Segments[0]; segment.Start != len(sequence) || segment.End != len(sequence) {
+		t.Errorf("got %+v, want an empty segment at the end", segment)
+	}
+}
@@ -0,0 +1,65 @@
+package dtw
+
+// FastDTW (Salvador & Chan, 2007): aligns copies of both sequences at half the resolution,
+// projects the resulting path back onto the full resolution, widens it by the radius and
+// only computes the cells inside. Recurses until the sequences are short enough to be
+// aligned exactly.
+func fastDtw(sequence1 [][]float64, sequence2 [][]float64, openBegin bool, openEnd bool, options *Options) []PathPoint {
+	minSize := options.Radius + 2
+	if len(sequence1) <= minSize || len(sequence2) <= minSize {
+		band := fullBand(len(sequence1), len(sequence2))
+		return alignInBand(sequence1, sequence2, band, openBegin, openEnd, options.Distance)
+	}
+
+	coarsePath := fastDtw(halveResolution(sequence1), halveResolution(sequence2), openBegin, openEnd, options)
+	band := projectPath(coarsePath, len(sequence1), len(sequence2), options.Radius)
+	return alignInBand(sequence1, sequence2, band, openBegin, openEnd, options.Distance)
+}
+
+// Averages each pair of consecutive frames into one
+func halveResolution(sequence [][]float64) [][]float64 {
+	halved := make([][]float64, (len(sequence)+1)/2)
+	for i := range halved {
+		first := sequence[2*i]
+		if 2*i+1 >= len(sequence) {
+			halved[i] = first
+			continue
+		}
+
+		second := sequence[2*i+1]
+		frame := make([]float64, min(len(first), len(second)))
+		for k := range frame {
+			frame[k] = (first[k] + second[k]) / 2
+		}
+		halved[i] = frame
+	}
+	return halved
+}
+
+// Builds the band of cells covered by a path found at half the resolution, widened by radius
+// cells in every direction
+func projectPath(coarsePath []PathPoint, columns int, rows int, radius int) []columnRange {
+	projected := make([]columnRange, rows)
+	for i := range projected {
+		projected[i] = columnRange{start: columns, end: 0}
+	}
+
+	// Each coarse cell covers a 2x2 block of cells at full resolution
+	for _, point := range coarsePath {
+		for i := 2 * point.Sequence2Index; i <= 2*point.Sequence2Index+1 && i < rows; i++ {
+			projected[i].start = min(projected[i].start, 2*point.Sequence1Index)
+			projected[i].end = max(projected[i].end, min(2*point.Sequence1Index+2, columns))
+		}
+	}
+
+	band := make([]columnRange, rows)
+	for i := range band {
+		start, end := columns, 0
+		for k := max(i-radius, 0); k <= min(i+radius, rows-1); k++ {
+			start = min(start, projected[k].start)
+			end = max(end, projected[k].end)
+		}
+		band[i] = columnRange{start: max(start-radius, 0), end: min(end+radius, columns)}
+	}
+	return band
+}
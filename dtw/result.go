@@ -0,0 +1,84 @@
+package dtw
+
+// A point of the warping path, matching a frame of sequence1 with a frame of sequence2
+type PathPoint struct {
+	Sequence1Index int
+	Sequence2Index int
+	// Accumulated cost of the path up to and including this point
+	Cost float64
+}
+
+// Range of sequence1 frames matched with one part of sequence2, End is exclusive
+type Segment struct {
+	Start int
+	End   int
+	// Average local cost of the path points inside the segment
+	Distance float64
+}
+
+// Result of an alignment, from which boundaries, confidence scores or plots can be built
+type Result struct {
+	// Warping path in forward order, with sequence1 indexes relative to the whole of sequence1
+	Path []PathPoint
+	// Accumulated cost of the whole path
+	Cost float64
+	// One segment per anchor of sequence2
+	Segments []Segment
+	// Cost divided by the length of the path, so alignments of different lengths can be compared
+	Distance float64
+}
+
+// Builds the result of a path, with one segment per anchor of sequence2
+//
+// A segment starts where the path first reaches its anchor and ends where the next
+// segment starts, the last segment ends right after the end of the path.
+func newResult(path []PathPoint, sequence2Anchors []int) *Result {
+	result := &Result{
+		Path:     path,
+		Segments: make([]Segment, len(sequence2Anchors)),
+	}
+	if len(path) == 0 {
+		return result
+	}
+
+	last := path[len(path)-1]
+	result.Cost = last.Cost
+	result.Distance = last.Cost / float64(len(path))
+
+	// Index in the path of the first point of each segment, plus the end of the path
+	firstPoints := make([]int, len(sequence2Anchors)+1)
+	point := 0
+	for k, anchor := range sequence2Anchors {
+		for point < len(path) && path[point].Sequence2Index < anchor {
+			point++
+		}
+		firstPoints[k] = point
+	}
+	firstPoints[len(sequence2Anchors)] = len(path)
+
+	for k := range sequence2Anchors {
+		first, next := firstPoints[k], firstPoints[k+1]
+		segment := &result.Segments[k]
+
+		if first < len(path) {
+			segment.Start = path[first].Sequence1Index
+		} else {
+			segment.Start = last.Sequence1Index + 1
+		}
+		if next < len(path) {
+			segment.End = path[next].Sequence1Index
+		} else {
+			segment.End = last.Sequence1Index + 1
+		}
+
+		if next > first {
+			cost := path[next-1].Cost
+			if first > 0 {
+				cost -= path[first-1].Cost
+			}
+			segment.Distance = cost / float64(next-first)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,22 @@
+package dtw
+
+// sequence1 is template, sequence2 is searched for inside sequence1
+//
+// sequence2 may start and end anywhere (open begin and open end) inside the search window
+// of sequence1 beginning at searchStart, which is twice as long as sequence2. The result has
+// a single segment holding the best matching start and end frames, and the cost of that match.
+//
+// The window is aligned with the algorithm of the options: the stripe follows the diagonal
+// starting at searchStart, so the match is expected to start within the margin of it.
+func RunSubsequenceDtw(sequence1 [][]float64, sequence2 [][]float64, searchStart int, options *Options) *Result {
+	if searchStart >= len(sequence1) || len(sequence2) == 0 {
+		return &Result{Segments: []Segment{{Start: searchStart, End: searchStart}}}
+	}
+
+	window := searchWindow(sequence1, sequence2, searchStart)
+	path := align(window, sequence2, true, true, options)
+	for i := range path {
+		path[i].Sequence1Index += searchStart
+	}
+	return newResult(path, []int{0})
+}
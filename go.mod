@@ -3,6 +3,9 @@ module github.com/sillsdev/go-aeneas
 go 1.21
 
 require (
+	github.com/hajimehoshi/go-mp3 v0.3.4
+	github.com/jfreymuth/oggvorbis v1.0.5
+	github.com/mewkiz/flac v1.0.12
 	github.com/sillsdev/espeak v0.0.0-20240426191507-717949d04cab
 	github.com/spf13/pflag v1.0.5
 )
@@ -11,22 +14,24 @@ require (
 	git.sr.ht/~sbinet/gg v0.5.0 // indirect
 	github.com/ajstarks/svgo v0.0.0-20211024235047-1546f124cd8b // indirect
 	github.com/campoy/embedmd v1.0.0 // indirect
-	github.com/go-audio/audio v1.0.0 // indirect
+	github.com/go-audio/audio v1.0.0
 	github.com/go-audio/riff v1.0.0 // indirect
-	github.com/go-audio/wav v1.1.0 // indirect
+	github.com/go-audio/wav v1.1.0
 	github.com/go-fonts/liberation v0.3.2 // indirect
 	github.com/go-latex/latex v0.0.0-20231108140139-5c1ce85aa4ea // indirect
 	github.com/go-pdf/fpdf v0.9.0 // indirect
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
 	github.com/mjanda/go-dtw v0.0.0-20151228212638-82a6e976a117 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/r9y9/gossp v0.0.1 // indirect
 	golang.org/x/image v0.14.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
-	gonum.org/v1/gonum v0.15.0 // indirect
-	gonum.org/v1/plot v0.14.0 // indirect
+	gonum.org/v1/gonum v0.15.0
+	gonum.org/v1/plot v0.14.0
 )
 
 require (
 	github.com/gopherjs/gopherjs v1.17.2 // indirect
+	github.com/icza/bitio v1.1.0 // indirect
+	github.com/jfreymuth/vorbis v1.0.2 // indirect
+	github.com/mewkiz/pkg v0.0.0-20230226050401-4010bf0fec14 // indirect
 )
@@ -2,19 +2,42 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
-	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/sillsdev/go-aeneas/audiodecoders"
 	"github.com/sillsdev/go-aeneas/audiogenerators"
+	"github.com/sillsdev/go-aeneas/boundary"
 	"github.com/sillsdev/go-aeneas/datatypes"
 	"github.com/sillsdev/go-aeneas/dtw"
 	"github.com/sillsdev/go-aeneas/mfcc"
+	"github.com/sillsdev/go-aeneas/syncmapwriters"
+	"github.com/sillsdev/go-aeneas/vad"
+	flag "github.com/spf13/pflag"
+)
+
+// Sample rate the input audio is converted to before computing its MFCC
+const wavSampleRate = 22050
+
+const (
+	// Each phrase is aligned on its own, starting where the previous one ended
+	alignmentModePhrase = "phrase"
+	// All phrases are concatenated and aligned at once against the whole recording
+	alignmentModeChapter = "chapter"
+)
+
+const (
+	// WAV, FLAC, MP3 and OGG/Vorbis decoded in Go, ffmpeg being the fallback for other files
+	audioDecoderNative = "native"
+	// Every file is converted by ffmpeg
+	audioDecoderFfmpeg = "ffmpeg"
 )
 
 var (
@@ -22,6 +45,10 @@ var (
 	batch          = ""
 	plot           = false
 	listGenerators = false
+	listFormats    = false
+	book           = ""
+	chapter        = 0
+	level          = ""
 	generator      = ""
 )
 
@@ -59,18 +86,62 @@ type PhraseReadResults struct {
  * Each individual line may fail to parse if the file is malformed,
  * and this error is passed along per line in the channel returned
  *
+ * With the plain text type, every line is a phrase whose id is its line number
+ * formatted with idFormat. With the multilevel plain text type, the phrases are
+ * paragraphs holding their sentences and words as children. With the unparsed text
+ * types, the phrases are the elements of an XHTML document selected by the parameters.
+ *
  * Closes the channel provided as input
  */
-func readPhrasesFromFile(filename string, phraseResults chan<- PhraseReadResults) {
+func readPhrasesFromFile(filename string, textType string, idFormat string, parameters *datatypes.Parameters, phraseResults chan<- PhraseReadResults) {
 	defer close(phraseResults)
 
+	switch textType {
+	case datatypes.TextTypeMultilevelPlain, datatypes.TextTypeUnparsed, datatypes.TextTypeMultilevelUnparsed:
+		// The whole document is needed, blank lines separate paragraphs and elements span lines
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			phraseResults <- PhraseReadResults{nil, err}
+			return
+		}
+
+		var phrases []*datatypes.Phrase
+		switch textType {
+		case datatypes.TextTypeMultilevelPlain:
+			phrases = datatypes.ParseMultilevelPlainText(strings.ReplaceAll(string(content), "\r\n", "\n"))
+		case datatypes.TextTypeUnparsed:
+			phrases, err = datatypes.ParseUnparsedText(bytes.NewReader(content), parameters)
+		case datatypes.TextTypeMultilevelUnparsed:
+			phrases, err = datatypes.ParseMultilevelUnparsedText(bytes.NewReader(content), parameters)
+		}
+		if err != nil {
+			phraseResults <- PhraseReadResults{nil, err}
+			return
+		}
+
+		for _, phrase := range phrases {
+			phraseResults <- PhraseReadResults{phrase, nil}
+		}
+		return
+	}
+
 	phrases, err := readFileLines(filename)
 	if err != nil {
 		phraseResults <- PhraseReadResults{nil, err}
 		return
 	}
 
+	plainPhrases := 0
 	for _, phrase := range phrases {
+		if textType == datatypes.TextTypePlain {
+			if datatypes.IsBlankLine(phrase) {
+				continue
+			}
+			plainPhrases++
+			phraseResults <- PhraseReadResults{datatypes.NewPlainPhrase(phrase, plainPhrases, idFormat), nil}
+			continue
+		}
+
 		parsedPhrase, err := datatypes.ParsePhrase(phrase)
 		if err != nil {
 			phraseResults <- PhraseReadResults{nil, err}
@@ -135,7 +206,7 @@ func generateWavFileForPhrase(tpv *datatypes.TaskProcessVariables, phrase *datat
 
 type GeneratedMfcCoefficients struct {
 	phraseAndWav PhraseWav
-	mfccResult   *[][]float64
+	mfccResult   *mfcc.Result
 }
 
 type MfccResults struct {
@@ -143,7 +214,7 @@ type MfccResults struct {
 	err  error
 }
 
-func generateMfccForWavFiles(tpv *datatypes.TaskProcessVariables, phrasesGenerated <-chan PhraseWavResults, mfccResults chan<- MfccResults) {
+func generateMfccForWavFiles(mfccOptions *mfcc.MfccOptions, phrasesGenerated <-chan PhraseWavResults, mfccResults chan<- MfccResults) {
 	defer close(mfccResults)
 
 	var wg sync.WaitGroup
@@ -159,17 +230,41 @@ func generateMfccForWavFiles(tpv *datatypes.TaskProcessVariables, phrasesGenerat
 		go func() {
 			defer wg.Done()
 			// do your mfcc, then write to mfccResults
-			results, err := mfcc.GenerateMfcc(phraseAndWav.phraseWavFilePath)
+			results, err := mfcc.GenerateMfcc(phraseAndWav.phraseWavFilePath, mfccOptions)
 			if err != nil {
 				mfccResults <- MfccResults{nil, err}
 				return
 			}
-			mfccResults <- MfccResults{&GeneratedMfcCoefficients{*phraseAndWav, &results}, nil}
+			mfccResults <- MfccResults{&GeneratedMfcCoefficients{*phraseAndWav, results}, nil}
 		}()
 	}
 	wg.Wait()
 }
 
+/**
+ * Waits until the MFCC of the given phrase has been generated
+ *
+ * MFCC results arrive in whatever order they finish, results for other phrases
+ * are kept in mfccPhrasesMap until their turn comes
+ */
+func waitForPhraseMfcc(phrase *datatypes.Phrase, mfccPhrasesMap map[string]*MfccResults, mfccPhraseResults <-chan MfccResults) (*GeneratedMfcCoefficients, error) {
+	for {
+		if result, ok := mfccPhrasesMap[phrase.PhraseIndex]; ok {
+			delete(mfccPhrasesMap, phrase.PhraseIndex)
+			return result.mfcc, nil
+		}
+
+		val, ok := <-mfccPhraseResults
+		if !ok {
+			return nil, fmt.Errorf("no MFCC generated for phrase %s", phrase.PhraseIndex)
+		}
+		if val.err != nil {
+			return nil, val.err
+		}
+		mfccPhrasesMap[val.mfcc.phraseAndWav.phrase.PhraseIndex] = &val
+	}
+}
+
 /**
  * Process task pipeline
  *
@@ -196,96 +291,285 @@ func processTask(results chan string, task *datatypes.Task, generator *datatypes
 	tpv.Println("Output  : ", tpv.Task.OutputFilename)
 	tpv.Println("Parameters : ", tpv.Parameters)
 
-	wavs := make(chan string)
-	go convertWav(wavs, tpv)
-
-	phraseReads := make(chan PhraseReadResults)
-	go readPhrasesFromFile(tpv.Task.PhraseFilename, phraseReads)
-	phrasesWithFiles := make(chan PhraseWavResults)
-	phraseOrder := make(chan *datatypes.Phrase)
+	if err := tpv.Task.Validate(); err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
 
-	go generateWavFilesForPhrases(tpv, phraseOrder, phraseReads, phrasesWithFiles)
-	mfccPhraseResults := make(chan MfccResults)
-	go generateMfccForWavFiles(tpv, phrasesWithFiles, mfccPhraseResults)
+	mfccOptions, err := mfcc.ParseMfccOptions(tpv.Parameters)
+	if err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
 
-	//fmt.Println("Number of Ordered Phrases Processed: ", len(phraseOrder))
+	textType := tpv.GetParameter("is_text_type")
+	if textType == "" {
+		textType = datatypes.TextTypeParsed
+	}
+	switch textType {
+	case datatypes.TextTypeParsed, datatypes.TextTypePlain, datatypes.TextTypeMultilevelPlain, datatypes.TextTypeUnparsed, datatypes.TextTypeMultilevelUnparsed:
+	default:
+		tpv.Println("Error: unknown is_text_type ", textType)
+		return
+	}
+	idFormat := tpv.GetParameter("os_task_file_id_regex")
+	if idFormat == "" {
+		idFormat = datatypes.DefaultIdFormat
+	}
+	if err := datatypes.CheckIdFormat(idFormat); err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
 
-	mfccPhrasesMap := make(map[string]*MfccResults)
+	// Parameters are all checked before any goroutine starts, an error returns while nothing
+	// is waiting on the channels yet
+	audioDecoder := tpv.GetParameter("audio_decoder")
+	if audioDecoder != "" && audioDecoder != audioDecoderNative && audioDecoder != audioDecoderFfmpeg {
+		tpv.Println("Error: unknown audio_decoder ", audioDecoder)
+		return
+	}
+	ffmpegTimeout, err := tpv.Parameters.GetFloat("ffmpeg_timeout", 0)
+	if err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
 
-	timeOffsetFloat, err := strconv.ParseFloat(tpv.Parameters.Get("is_audio_file_detect_head_max"), 64)
+	// The head (silence, introduction) and tail of the recording are detected within these
+	// bounds in seconds, a maximum of 0 disables the detection
+	headMin, err := tpv.Parameters.GetFloat("is_audio_file_detect_head_min", 0)
+	if err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
+	headMax, err := tpv.Parameters.GetFloat("is_audio_file_detect_head_max", 0)
+	if err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
+	tailMin, err := tpv.Parameters.GetFloat("is_audio_file_detect_tail_min", 0)
+	if err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
+	tailMax, err := tpv.Parameters.GetFloat("is_audio_file_detect_tail_max", 0)
 	if err != nil {
 		tpv.Println("Error: ", err)
 		return
 	}
-	tpv.Println("Initial Time Offset: ", timeOffsetFloat)
-	timeOffset := (int)(timeOffsetFloat * 22050)
 
-	file, err := os.Create(tpv.Task.OutputFilename)
+	dtwOptions := dtw.DefaultOptions()
+	dtwOptions.Distance, err = dtw.GetDistanceFunc(tpv.GetParameter("dtw_distance"))
 	if err != nil {
-		fmt.Println("Error:", err)
+		tpv.Println("Error: ", err)
+		return
+	}
+	dtwOptions.Algorithm, err = dtw.GetAlgorithm(tpv.GetParameter("dtw_algorithm"))
+	if err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
+	// In seconds, when it is not set the stripe keeps the default width in frames of the dtw package
+	dtwMargin, err := tpv.Parameters.GetFloat("dtw_margin", 0)
+	if err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
+	dtwOptions.Radius, err = tpv.Parameters.GetInt("dtw_radius", dtwOptions.Radius)
+	if err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
+	if dtwMargin < 0 {
+		tpv.Println("Error: dtw_margin is negative ", dtwMargin)
+		return
+	}
+	if err := dtwOptions.Validate(); err != nil {
+		tpv.Println("Error: ", err)
 		return
 	}
-	defer file.Close()
 
-	splitDescription := strings.Split(tpv.Task.Description, " ")
-	book := strings.TrimSpace(splitDescription[0])
-	chapter := strings.TrimSpace(splitDescription[1])
+	syncMapWriter, err := syncmapwriters.GetSyncMapWriter(tpv.GetParameter("os_task_file_format"))
+	if err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
+	// Read again by the writer, checked here so that a typo doesn't cost a whole alignment
+	if err := syncMapWriter.Check(tpv.Task, tpv.Parameters); err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
 
-	content := fmt.Sprintf(`\id %s
-\c %s
-\level phrase
-\separators . ? ! : ; ,
-`, book, chapter)
+	alignmentMode := tpv.GetParameter("task_alignment_mode")
+	if alignmentMode != "" && alignmentMode != alignmentModePhrase && alignmentMode != alignmentModeChapter {
+		tpv.Println("Error: unknown task_alignment_mode ", alignmentMode)
+		return
+	}
 
-	// Write content to the file
-	_, err = file.WriteString(content)
+	// The input audio is always converted to wavSampleRate, so its frame rate is already known
+	frameRate, err := mfccOptions.FrameRate(wavSampleRate)
 	if err != nil {
-		fmt.Println("Error:", err)
+		tpv.Println("Error: ", err)
 		return
 	}
-	tpv.Println("Timing File created and written successfully.")
+	vadOptions, err := vad.ParseOptions(tpv.Parameters, frameRate)
+	if err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
+	boundaryOptions, err := boundary.ParseOptions(tpv.Parameters, frameRate)
+	if err != nil {
+		tpv.Println("Error: ", err)
+		return
+	}
+
+	wavs := make(chan WavResults)
+	go convertWav(wavs, tpv, ffmpegTimeout)
+
+	phraseReads := make(chan PhraseReadResults)
+	go readPhrasesFromFile(tpv.Task.PhraseFilename, textType, idFormat, tpv.Parameters, phraseReads)
+	phrasesWithFiles := make(chan PhraseWavResults)
+	phraseOrder := make(chan *datatypes.Phrase)
+
+	go generateWavFilesForPhrases(tpv, phraseOrder, phraseReads, phrasesWithFiles)
+	mfccPhraseResults := make(chan MfccResults)
+	go generateMfccForWavFiles(mfccOptions, phrasesWithFiles, mfccPhraseResults)
+
+	//fmt.Println("Number of Ordered Phrases Processed: ", len(phraseOrder))
 
+	mfccPhrasesMap := make(map[string]*MfccResults)
+
+	var inputMfcc *mfcc.Result
 	mfccResultsChan := make(chan error)
 	go func() {
-		mfccInputResults, err := mfcc.GenerateMfcc(<-wavs)
+		wav := <-wavs
+		if wav.err != nil {
+			mfccResultsChan <- wav.err
+			return
+		}
+
+		mfccInputResults, err := mfcc.GenerateMfcc(wav.wavFilePath, mfccOptions)
 		if err != nil {
 			mfccResultsChan <- err
 		} else {
-			tpv.MfccInputResults = mfccInputResults
+			inputMfcc = mfccInputResults
+			tpv.MfccInputResults = mfccInputResults.Coefficients
 			mfccResultsChan <- nil
 		}
 	}()
 
 	if err := <-mfccResultsChan; err != nil {
 		tpv.Println("Error handling MFCC ", err)
+		return
 	}
 
-	for phrase := range phraseOrder {
-		nextPhrase := phrase.PhraseIndex
+	// Frame indexes are converted to and from seconds using the frame rate of the input audio
+	if dtwMargin > 0 {
+		dtwOptions.Margin = inputMfcc.SecondsToFrame(dtwMargin)
+	}
+
+	speech := vad.Detect(inputMfcc.LogEnergy, vadOptions)
 
-		ok := false
-		_, ok = mfccPhrasesMap[nextPhrase]
+	headFrame := min(inputMfcc.SecondsToFrame(headMin), len(tpv.MfccInputResults))
+	if headMax > 0 {
+		headFrame = vad.DetectHead(speech, headFrame, inputMfcc.SecondsToFrame(headMax))
+	}
+	tailFrames := inputMfcc.SecondsToFrame(tailMin)
+	if tailMax > 0 {
+		tailFrames = vad.DetectTail(speech, tailFrames, inputMfcc.SecondsToFrame(tailMax))
+	}
+	endFrame := max(len(tpv.MfccInputResults)-tailFrames, headFrame)
+	tpv.Println("Audio head ends at ", inputMfcc.FrameToTime(headFrame), ", tail starts at ", inputMfcc.FrameToTime(endFrame))
+
+	// Only the part between the head and the tail is aligned
+	audioMfcc := tpv.MfccInputResults[:endFrame]
+	timeOffset := headFrame
+
+	// Aligned phrases, written once their boundaries have been adjusted
+	fragments := make([]boundary.Fragment, 0)
+	phrases := make([]*datatypes.Phrase, 0)
+	addFragment := func(beginFrame int, endFrame int, phrase *datatypes.Phrase) {
+		fragments = append(fragments, boundary.Fragment{Begin: beginFrame, End: endFrame, Characters: len([]rune(phrase.PhraseText))})
+		phrases = append(phrases, phrase)
+	}
 
-		for !ok {
-			val := <-mfccPhraseResults
-			mfccPhrasesMap[val.mfcc.phraseAndWav.phrase.PhraseIndex] = &val
-			_, ok = mfccPhrasesMap[nextPhrase]
+	if alignmentMode == alignmentModeChapter {
+		// Concatenate the synthesized phrases, remembering the frame each one starts at,
+		// and align them all at once against the recording
+		phraseMfccs := make([]*GeneratedMfcCoefficients, 0)
+		synthesized := make([][]float64, 0)
+		anchors := make([]int, 0)
+		for phrase := range phraseOrder {
+			phraseMfcc, err := waitForPhraseMfcc(phrase, mfccPhrasesMap, mfccPhraseResults)
+			if err != nil {
+				tpv.Println("Error: ", err)
+				return
+			}
+			phraseMfccs = append(phraseMfccs, phraseMfcc)
+			anchors = append(anchors, len(synthesized))
+			synthesized = append(synthesized, phraseMfcc.mfccResult.Coefficients...)
 		}
 
-		tpv.Println("Handling phrase MFCC/DTW: ", mfccPhrasesMap[nextPhrase].mfcc.phraseAndWav.phrase.PhraseIndex)
-		oldTimeOffset := timeOffset
-		timeOffset = dtw.RunDtw(tpv.MfccInputResults, *mfccPhrasesMap[nextPhrase].mfcc.mfccResult, timeOffset)
+		startFrame := timeOffset
+		tpv.Println("Handling chapter MFCC/DTW: ", len(phraseMfccs), " phrases, ", len(synthesized), " frames")
+		result := dtw.RunGlobalDtw(audioMfcc[startFrame:], synthesized, anchors, dtwOptions)
+		tpv.Println("Chapter DTW distance: ", result.Distance)
 
-		temp := fmt.Sprintf("%d\t%d\t%s\n", oldTimeOffset/22050, timeOffset/22050, mfccPhrasesMap[nextPhrase].mfcc.phraseAndWav.phrase.PhraseIndex)
-		_, err := file.WriteString(temp)
-		if err != nil {
-			tpv.Println("Error writing file! ", err)
+		for i, phraseMfcc := range phraseMfccs {
+			segment := result.Segments[i]
+			addFragment(startFrame+segment.Start, startFrame+segment.End, phraseMfcc.phraseAndWav.phrase)
 		}
+	} else {
+		for phrase := range phraseOrder {
+			phraseMfcc, err := waitForPhraseMfcc(phrase, mfccPhrasesMap, mfccPhraseResults)
+			if err != nil {
+				tpv.Println("Error: ", err)
+				return
+			}
 
-		mfccPhrasesMap[nextPhrase] = nil
-		// update time offset
+			tpv.Println("Handling phrase MFCC/DTW: ", phraseMfcc.phraseAndWav.phrase.PhraseIndex)
+			// The phrase may start anywhere after the end of the previous one, so pauses
+			// and an inexact head offset do not shift its boundaries
+			result := dtw.RunSubsequenceDtw(audioMfcc, phraseMfcc.mfccResult.Coefficients, timeOffset, dtwOptions)
+			segment := result.Segments[0]
+			timeOffset = segment.End
+
+			addFragment(segment.Start, segment.End, phraseMfcc.phraseAndWav.phrase)
+		}
 	}
 
+	nonspeech := vad.NonspeechIntervals(speech)
+	boundary.Adjust(fragments, nonspeech, boundaryOptions)
+
+	// Children of multilevel text are aligned inside the interval of their parent
+	childAlignment := &childAlignment{tpv, inputMfcc, mfccOptions, dtwOptions, boundaryOptions, nonspeech}
+	syncMap := &datatypes.SyncMap{
+		Fragments: make([]*datatypes.SyncMapFragment, 0, len(fragments)),
+		Duration:  inputMfcc.FrameToTime(len(tpv.MfccInputResults)),
+	}
+	for i, fragment := range fragments {
+		syncMapFragment := &datatypes.SyncMapFragment{
+			Id:    phrases[i].PhraseIndex,
+			Begin: inputMfcc.FrameToTime(fragment.Begin),
+			End:   inputMfcc.FrameToTime(fragment.End),
+			Text:  phrases[i].PhraseText,
+		}
+		if len(phrases[i].Children) > 0 {
+			tpv.Println("Handling children MFCC/DTW: ", phrases[i].PhraseIndex)
+			syncMapFragment.Children, err = childAlignment.align(fragment, phrases[i].Children)
+			if err != nil {
+				tpv.Println("Error: ", err)
+				return
+			}
+		}
+		syncMap.Fragments = append(syncMap.Fragments, syncMapFragment)
+	}
+
+	if err := writeSyncMap(tpv, syncMapWriter, syncMap); err != nil {
+		tpv.Println("Error writing file! ", err)
+		return
+	}
+	tpv.Println("Timing File created and written successfully.")
+
 	if plot {
 		mfcc.PlotMFCC(tpv.MfccInputResults)
 	}
@@ -293,6 +577,19 @@ func processTask(results chan string, task *datatypes.Task, generator *datatypes
 	tpv.Println("Done with ", tpv.Task.Description, "!")
 }
 
+/**
+ * Writes the sync map to the output file of the task in the format of syncMapWriter
+ *
+ * The sync map is written in memory first, so that a writer error leaves an existing output file untouched
+ */
+func writeSyncMap(tpv *datatypes.TaskProcessVariables, syncMapWriter datatypes.SyncMapWriter, syncMap *datatypes.SyncMap) error {
+	var output bytes.Buffer
+	if err := syncMapWriter.Write(tpv.Task, tpv.Parameters, syncMap, &output); err != nil {
+		return err
+	}
+	return os.WriteFile(tpv.Task.OutputFilename, output.Bytes(), 0666)
+}
+
 func createTempDir() string {
 	TempDir, err := os.MkdirTemp("", "goaeneas")
 	if err != nil {
@@ -301,11 +598,77 @@ func createTempDir() string {
 	return TempDir
 }
 
-func convertWav(wavs chan<- string, tpv *datatypes.TaskProcessVariables) {
+type WavResults struct {
+	wavFilePath string
+	err         error
+}
+
+/**
+ * Converts the input audio to a mono WAV file at wavSampleRate
+ *
+ * Audio is decoded natively (WAV, FLAC, MP3, OGG/Vorbis) unless the `audio_decoder`
+ * parameter is set to ffmpeg. ffmpeg is also used as a fallback when native decoding
+ * fails and ffmpeg is installed. ffprobe is optional, it only describes the input and
+ * gives the progress of the conversion a total.
+ *
+ * ffmpeg and ffprobe are looked up in the PATH unless `ffmpeg_path` / `ffprobe_path` are
+ * set, and are stopped after `ffmpeg_timeout` seconds when it is set.
+ */
+func convertWav(wavs chan<- WavResults, tpv *datatypes.TaskProcessVariables, timeout float64) {
 	filepath := tpv.GetWavFilepath()
-	out, _ := exec.Command("ffmpeg", "-i", tpv.Task.AudioFilename, "-acodec", "pcm_s16le", "-ac", "1", "-ar", "22050", filepath).CombinedOutput() //Swapped to a sample rate of 22050 from 16000
-	wavs <- filepath
-	tpv.Println("ffmpeg output : ", string(out))
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout*float64(time.Second)))
+		defer cancel()
+	}
+
+	ffmpeg := audiodecoders.NewFfmpeg()
+	if path := tpv.GetParameter("ffmpeg_path"); path != "" {
+		ffmpeg.FfmpegPath = path
+	}
+	if path := tpv.GetParameter("ffprobe_path"); path != "" {
+		ffmpeg.FfprobePath = path
+	}
+
+	if tpv.GetParameter("audio_decoder") != audioDecoderFfmpeg {
+		err := audiodecoders.ConvertToWav(tpv.Task.AudioFilename, filepath, wavSampleRate)
+		if err == nil {
+			wavs <- WavResults{filepath, nil}
+			return
+		}
+
+		tpv.Println("Native decoding failed: ", err)
+		if !ffmpeg.Available() {
+			wavs <- WavResults{"", err}
+			return
+		}
+		tpv.Println("Falling back to ffmpeg")
+	}
+
+	wavs <- convertWavWithFfmpeg(ctx, ffmpeg, tpv, filepath)
+}
+
+func convertWavWithFfmpeg(ctx context.Context, ffmpeg *audiodecoders.Ffmpeg, tpv *datatypes.TaskProcessVariables, filepath string) WavResults {
+	// ffmpeg reports the errors which matter, without the probe there is only no progress
+	info, err := ffmpeg.Probe(ctx, tpv.Task.AudioFilename)
+	if err != nil {
+		tpv.Println("Could not probe the input audio: ", err)
+	} else {
+		tpv.Println("Input audio : ", info.Codec, ", ", info.Channels, " channels, ", info.SampleRate, " Hz, ", info.Duration, " seconds")
+	}
+
+	progress := func(seconds float64) {
+		if logLevel > 0 && info != nil {
+			fmt.Fprintf(os.Stderr, "Converting %s: %.0f%%\n", tpv.Task.AudioFilename, 100*min(seconds/info.Duration, 1))
+		}
+	}
+
+	if err := ffmpeg.ConvertToWav(ctx, tpv.Task.AudioFilename, filepath, wavSampleRate, progress); err != nil {
+		return WavResults{"", err}
+	}
+	return WavResults{filepath, nil}
 }
 
 func main() {
@@ -322,6 +685,15 @@ func main() {
 		os.Exit(0)
 	}
 
+	if listFormats {
+		fmt.Println("Output formats available:")
+		for _, writer := range syncmapwriters.GetSyncMapWriters() {
+			fmt.Printf("\t%s\n", writer.GetName())
+		}
+
+		os.Exit(0)
+	}
+
 	tasks := []*datatypes.Task{}
 	if len(batch) > 0 {
 		//fmt.Println("Batch file:", batch)
@@ -334,13 +706,16 @@ func main() {
 		if err != nil {
 			log.Fatal("Error parsing batch json file", err)
 		}
-	} else if len(os.Args) >= 5 {
+	} else if args := flag.Args(); len(args) >= 4 {
 		task := &datatypes.Task{
 			Description:    "",
-			AudioFilename:  os.Args[1],
-			PhraseFilename: os.Args[2],
-			Parameters:     os.Args[3],
-			OutputFilename: os.Args[4],
+			AudioFilename:  args[0],
+			PhraseFilename: args[1],
+			Parameters:     args[2],
+			OutputFilename: args[3],
+			Book:           book,
+			Chapter:        chapter,
+			Level:          level,
 		}
 		tasks = append(tasks, task)
 	}
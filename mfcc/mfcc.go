@@ -4,48 +4,93 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"time"
 
 	"github.com/go-audio/wav"
 	"gonum.org/v1/gonum/dsp/fourier"
-	"gonum.org/v1/gonum/dsp/window"
 )
 
-func GenerateMfcc(inFileName string) ([][]float64, error) {
+// MFCC of an audio file, with what is needed to convert frame indexes to time
+type Result struct {
+	Coefficients [][]float64 // One row of coefficients per frame
+	SampleRate   int         // Sample rate of the audio file, read from the WAV header
+	FrameSize    int         // Number of samples in a frame
+	FrameStep    int         // Number of samples between the starts of two consecutive frames (hop)
+	LogEnergy    []float64   // log10 of the energy of each frame, used for voice activity detection
+}
+
+// Number of frames per second
+func (result *Result) FrameRate() float64 {
+	return float64(result.SampleRate) / float64(result.FrameStep)
+}
+
+// Time at which a frame starts, exact to the nanosecond
+func (result *Result) FrameToTime(frame int) time.Duration {
+	return time.Duration(int64(frame) * int64(result.FrameStep) * int64(time.Second) / int64(result.SampleRate))
+}
+
+// Index of the frame starting closest to a time in seconds
+func (result *Result) SecondsToFrame(seconds float64) int {
+	return int(math.Round(seconds * result.FrameRate()))
+}
+
+func GenerateMfcc(inFileName string, options *MfccOptions) (*Result, error) {
 	//fmt.Println("Beginning mfcc generation for inputted .wav file: ", inFileName)
 
-	signal, err := mfccLoadSignal(inFileName)
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	signal, sampleRate, err := mfccLoadSignal(inFileName, options.EmphasisFactor)
+	if err != nil {
+		return nil, err
+	}
+	frameSize, frameStep, err := options.frameSamples(sampleRate)
 	if err != nil {
 		return nil, err
 	}
-	normalizedSignal := mfccNormalize(signal)
-	framedSignal := mfccFrameSignal(normalizedSignal)
-	windowedSignal := mfccWindowSignal(framedSignal)
-	fft := mfccFFT(framedSignal, windowedSignal)
-	powerSpectrum := mfccPowerSpectrum(fft)
-	triangularFiler := mfccTriangularFilter(powerSpectrum)
-	weightedSignal := mfccWeighSignal(triangularFiler)
-	mfcc := mfccDCT(weightedSignal)
 
-	return mfcc, nil
+	normalizedSignal, err := mfccNormalize(signal)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", inFileName, err)
+	}
+	framedSignal := mfccFrameSignal(normalizedSignal, frameSize, frameStep)
+	windowedSignal := mfccWindowSignal(framedSignal, windowFunctions[options.WindowType])
+	fftSize := mfccFFTSize(framedSignal)
+	fft := mfccFFT(windowedSignal, fftSize)
+	powerSpectrum := mfccPowerSpectrum(fft, fftSize)
+	logEnergy := mfccFrameLogEnergy(powerSpectrum)
+	filterbank := mfccMelFilterbank(options.FilterCount, options.LowerFrequency, options.UpperFrequency, float64(sampleRate), fftSize)
+	filterEnergies := mfccTriangularFilter(powerSpectrum, filterbank)
+	logEnergies := mfccLog(filterEnergies)
+	mfcc := mfccDCT(logEnergies, mfccDCTMatrix(options.FilterCount, options.Size))
+	mfcc = mfccNormalizeCepstrum(mfcc, options.Normalization)
+	mfcc = mfccAppendDeltas(mfcc, options.DeltaOrder, options.DeltaWindow)
+
+	return &Result{
+		Coefficients: mfcc,
+		SampleRate:   sampleRate,
+		FrameSize:    frameSize,
+		FrameStep:    frameStep,
+		LogEnergy:    logEnergy,
+	}, nil
 }
 
-func mfccLoadSignal(inFileName string) ([]float64, error) {
-
-	const preEmphasis = 0.95 // PreEmphasis Coefficient -> Modify coefficient as needed
+func mfccLoadSignal(inFileName string, preEmphasis float64) ([]float64, int, error) {
 
 	audiofile, err := os.Open(inFileName) // OS opens inFileName; takes a string filepath
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	decoder := wav.NewDecoder(audiofile) // Load a decoder for the loaded wav file
 	if decoder == nil {
-		return nil, fmt.Errorf("could not decode file")
+		return nil, 0, fmt.Errorf("could not decode file")
 	}
 
 	audiobuffer, err := decoder.FullPCMBuffer() // FullPCMBuffer takes a pointer to a decoder and returns a buffer
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	defer audiofile.Close()
@@ -54,19 +99,22 @@ func mfccLoadSignal(inFileName string) ([]float64, error) {
 
 	signal := audiobuffer.Data
 
-	//Load audio buffer data into new signal array
+	//Load audio buffer data into new signal array, filtered as x[n] - a*x[n-1] from the original samples
 	signal64 := make([]float64, len(signal))
 	for i := 0; i < len(signal); i++ {
 		signal64[i] = float64(signal[i])
-	}
-	for i := 1; i < len(signal); i++ {
-		signal64[i] = signal64[i] - preEmphasis*signal64[i-1]
+		if i > 0 {
+			signal64[i] -= preEmphasis * float64(signal[i-1])
+		}
 	}
 
-	return signal64, nil
+	return signal64, int(decoder.SampleRate), nil
 }
 
-func mfccNormalize(signal64 []float64) []float64 {
+func mfccNormalize(signal64 []float64) ([]float64, error) {
+	if len(signal64) == 0 {
+		return nil, fmt.Errorf("no audio samples")
+	}
 	currentMax := signal64[0]
 	if currentMax < 0 {
 		currentMax = currentMax * -1
@@ -82,26 +130,24 @@ func mfccNormalize(signal64 []float64) []float64 {
 		}
 	}
 	absMax := currentMax
+	if absMax == 0 {
+		return nil, fmt.Errorf("the audio is silent")
+	}
 
 	for i := 0; i < len(signal64); i++ {
 		signal64[i] = signal64[i] / absMax
 	}
 
-	return signal64
+	return signal64, nil
 }
 
-// 44100(samplingRate) * 0.03(milliseconds) = 1323 (frame size)
-// Sampling Rate = 44.1kHz
+// 22050(samplingRate) * 0.03(seconds) = 661 (frame size)
+// The sampling rate comes from the WAV header, so frames always last the same time whatever the rate
 // Frame Length = 20-30ms is a good choice
 
-func mfccFrameSignal(signal64 []float64) [][]float64 {
-	var sampleRate = 44100.0
-	var frameLength = 0.03
-	var frameOverlap = 0.5
-	var frameSize = int(sampleRate * frameLength)                                        // int: can't have a fraction of a sample
-	var frameStep = int(sampleRate * frameLength * frameOverlap)                         // int: indexes are whole numbers
-	var numFrames = (float64(len(signal64)) / (sampleRate * frameLength)) / frameOverlap // +1 if partial
-	if numFrames != float64(int(numFrames)) {                                            // If there is a partial frame truncate and add 1, then handle the the partial/tail frame.
+func mfccFrameSignal(signal64 []float64, frameSize int, frameStep int) [][]float64 {
+	var numFrames = float64(len(signal64)) / float64(frameStep) // +1 if partial
+	if numFrames != float64(int(numFrames)) {                   // If there is a partial frame truncate and add 1, then handle the the partial/tail frame.
 		numFrames = float64(int(numFrames))
 	}
 	// fmt.Println("signal64: ", len(signal64))
@@ -123,157 +169,295 @@ func mfccFrameSignal(signal64 []float64) [][]float64 {
 }
 
 // End Framing the Signal
-// Start Windowing the Signal (Hamming Window)
+// Start Windowing the Signal (Hamming Window by default, see MfccOptions.WindowType)
 // https://pkg.go.dev/gonum.org/v1/gonum/dsp/window#example-Hamming
 
-// Have to copy the frames because I need both the original and windowed frames for the FFT.
+// Have to copy the frames because the frames share their samples with the signal (they overlap).
 
-func mfccWindowSignal(frames [][]float64) [][]float64 {
+func mfccWindowSignal(frames [][]float64, windowFunction func([]float64) []float64) [][]float64 {
 	goingHam := make([][]float64, len(frames))
 	for i := 0; i < len(frames); i++ {
 		goingHam[i] = append(goingHam[i], frames[i]...)
 	}
 
 	for i := 0; i < len(goingHam); i++ {
-		window.Hamming(goingHam[i]) // Changes data in place according to documentation
+		windowFunction(goingHam[i]) // Changes data in place according to documentation
 	}
 
 	return goingHam
 }
 
-// Now the overlap-add step. https://en.wikipedia.org/wiki/Overlap%E2%80%93add_method
-// recombining the frames accounting for the overlap.
-
-// - FFT then overlap-add
-// - Overlap-add method
-// There doesn't seem to be a library for this, so, like framing, do it manually.
-// So even though the slice of slices are independent from each other and so there isn't an issue with the windowing function changing the values of the other frames, I still need to account for the overlap?
+// FFT of each windowed frame, zero padded to fftSize
+// https://pkg.go.dev/gonum.org/v1/gonum/dsp/fourier
+// For real input only the first fftSize/2+1 coefficients are unique, which is what Coefficients returns (one-sided spectrum)
 
-func mfccFFT(frames [][]float64, goingHam [][]float64) [][]complex128 {
-	fftCoefficients := make([][]complex128, len(frames))
+func mfccFFT(goingHam [][]float64, fftSize int) [][]complex128 {
+	fftCoefficients := make([][]complex128, len(goingHam))
+	fft := fourier.NewFFT(fftSize)
+	padded := make([]float64, fftSize)
 
-	for i := 0; i < len(frames); i++ {
-		fft := fourier.NewFFT(len(frames[i]))
-		fftCoefficients[i] = fft.Coefficients(nil, goingHam[i])
+	for i := 0; i < len(goingHam); i++ {
+		copy(padded, goingHam[i])
+		for j := len(goingHam[i]); j < fftSize; j++ {
+			padded[j] = 0
+		}
+		fftCoefficients[i] = fft.Coefficients(nil, padded)
 	}
 
 	return fftCoefficients
 }
 
-// https://en.wikipedia.org/wiki/Triangular_function
-// Convolution: Operation that combines two signals to produce a third signal. - this should include/be the overlap-add step.
-// https://pkg.go.dev/github.com/brettbuddin/fourier@v0.1.1#section-readme
-// END FFT
+// Smallest power of two holding the longest frame, so every frame shares the same frequency bins
+func mfccFFTSize(frames [][]float64) int {
+	longest := 1
+	for i := 0; i < len(frames); i++ {
+		if len(frames[i]) > longest {
+			longest = len(frames[i])
+		}
+	}
+
+	fftSize := 1
+	for fftSize < longest {
+		fftSize *= 2
+	}
+	return fftSize
+}
 
-// Start Power Spectrum - Triangular filtering Pre-Step
-// I'm squaring the magnitude (so abs value) of the complex numbers, But im extracting the real numbers and discarding the imaginary numbers.
+// Start Power Spectrum (periodogram)
+// https://en.wikipedia.org/wiki/Periodogram
+// Squared magnitude of the complex coefficients, divided by the FFT size
 
-func mfccPowerSpectrum(fftCoefficients [][]complex128) [][]float64 {
+func mfccPowerSpectrum(fftCoefficients [][]complex128, fftSize int) [][]float64 {
 
 	powerSpectrum := make([][]float64, len(fftCoefficients))
 	for i := 0; i < len(fftCoefficients); i++ {
 		powerSpectrum[i] = make([]float64, len(fftCoefficients[i]))
 		for j := 0; j < len(fftCoefficients[i]); j++ {
-			magnitude := math.Sqrt(real(fftCoefficients[i][j])*real(fftCoefficients[i][j]) + imag(fftCoefficients[i][j])*imag(fftCoefficients[i][j]))
-			powerSpectrum[i][j] = magnitude * magnitude
+			re, im := real(fftCoefficients[i][j]), imag(fftCoefficients[i][j])
+			powerSpectrum[i][j] = (re*re + im*im) / float64(fftSize)
 		}
 	}
 
 	return powerSpectrum
 }
 
+// Total energy of each frame, on a log10 scale and floored so silent frames don't produce -Inf
+// Unlike the coefficients it is not affected by the cepstral normalization, so thresholds on it stay meaningful
+
+func mfccFrameLogEnergy(powerSpectrum [][]float64) []float64 {
+	const energyFloor = 1e-10
+
+	logEnergy := make([]float64, len(powerSpectrum))
+	for i := 0; i < len(powerSpectrum); i++ {
+		energy := 0.0
+		for j := 0; j < len(powerSpectrum[i]); j++ {
+			energy += powerSpectrum[i][j]
+		}
+		logEnergy[i] = math.Log10(math.Max(energy, energyFloor))
+	}
+	return logEnergy
+}
+
 // End Power Spectrum
 
 // Start Triangular Filtering - Mel Filter Banks
-// https://www.statistics.com/glossary/triangular-filter/#:~:text=As%20compared%20to%20the%20rectangular,short%20course%20Time%20Series%20Forecasting%20
 // https://en.wikipedia.org/wiki/Mel_scale
-// https://en.wikipedia.org/wiki/Periodogram
+// http://practicalcryptography.com/miscellaneous/machine-learning/guide-mel-frequency-cepstral-coefficients-mfccs/
 
-// Take the magnitude squared of the complex Fourier coefficients - This is the power spectrum
-// Map the power spectrum onto the Mel scale using a filterbank.
+// The mel scale is applied to frequencies (not to power values): the filter edges are spaced evenly
+// on the mel scale between lowFreq and highFreq, then converted back to Hz and to FFT bins.
+// Each filter rises linearly from its left edge to its center and falls back to zero at its right edge.
 
-// https://en.wikipedia.org/wiki/Mel_scale
-//melScale := 2595 * math.Log10(1+(sampleRate/2)/700) // Divide by 2 for Nyquist frequency
-//filterBankSize := 26                                // Number of filters
+func hertzToMel(frequency float64) float64 {
+	return 2595 * math.Log10(1+frequency/700)
+}
 
-// NOTE! This is NOT like windowing/framing. Framing we can think of as something we applied horizontally all along the data. The filter(s) we are applying we apply vertically.
+func melToHertz(mel float64) float64 {
+	return 700 * (math.Pow(10, mel/2595) - 1)
+}
 
-// Map the power spectrum onto the Mel scale
-// melScale := 2595 * math.Log10(1+(each_index_of_powerSpectrum/2)/700) // Divide by 2 for Nyquist frequency
+// Returns one row per filter, with one weight per bin of the one-sided spectrum (fftSize/2+1 bins)
+func mfccMelFilterbank(filters int, lowFreq float64, highFreq float64, sampleRate float64, fftSize int) [][]float64 {
+	nyquist := sampleRate / 2
+	if highFreq > nyquist {
+		highFreq = nyquist
+	}
 
-func mfccTriangularFilter(powerSpectrum [][]float64) [][]float64 {
-	for i := 0; i < len(powerSpectrum); i++ {
-		for j := 0; j < len(powerSpectrum[i]); j++ {
-			powerSpectrum[i][j] = 2595 * math.Log10(1+(float64(powerSpectrum[i][j])/2)/700) // Divide by 2 for Nyquist frequency
+	lowMel := hertzToMel(lowFreq)
+	highMel := hertzToMel(highFreq)
+
+	// filters+2 edges: each filter spans from edge i to edge i+2, centered on edge i+1
+	binFrequency := sampleRate / float64(fftSize)
+	edges := make([]float64, filters+2)
+	for i := range edges {
+		mel := lowMel + float64(i)*(highMel-lowMel)/float64(filters+1)
+		edges[i] = melToHertz(mel) / binFrequency // Fractional FFT bin of the edge
+	}
+
+	bins := fftSize/2 + 1
+	filterbank := make([][]float64, filters)
+	for i := 0; i < filters; i++ {
+		filterbank[i] = make([]float64, bins)
+		left, center, right := edges[i], edges[i+1], edges[i+2]
+		for j := 0; j < bins; j++ {
+			bin := float64(j)
+			if bin > left && bin <= center {
+				filterbank[i][j] = (bin - left) / (center - left)
+			} else if bin > center && bin < right {
+				filterbank[i][j] = (right - bin) / (right - center)
+			}
 		}
 	}
-	return powerSpectrum
+
+	return filterbank
 }
 
-// ---Filter Bank Process---
-// The filter banks are vertical
-// Apply the filter banks to the power spectrum
-// For range of PowerSpectrum multiply x adjacent indices by the filter bank values
-// add them together to get the weighted sum? take the log of the result
+// Filter bank energies: for each frame, the power spectrum weighted by each filter and summed
+
+func mfccTriangularFilter(powerSpectrum [][]float64, filterbank [][]float64) [][]float64 {
+	energies := make([][]float64, len(powerSpectrum))
+	for i := 0; i < len(powerSpectrum); i++ { // Index of frames
+		energies[i] = make([]float64, len(filterbank))
+		for k := 0; k < len(filterbank); k++ { // Index of filters
+			for j := 0; j < len(powerSpectrum[i]) && j < len(filterbank[k]); j++ { // Index of bins
+				energies[i][k] += powerSpectrum[i][j] * filterbank[k][j]
+			}
+		}
+	}
+	return energies
+}
 
-// Does every filterbank get applied across the entire spectrum? or is it dynamic, one filter per "section size"?
-// Meaning the entire set of filterbanks applied to every part of the spectrum.
 // End Triangular Filtering
-// [0, 1, 2, 3, 4, 5, 6, 7, 8, 9] - indexes
-// [1, 1, 1, 1, 1, 1, 1, 1, 1, 1] - values
 
-// Weighted output through summing
-// [0+12, 0+1+23, 01+2+34, 12+3+45, 23+4+56, 34+5+67, 45+6+78, 56+7+89, 67+8+9, 78+9]
+// Log of the filter bank energies, floored so silent frames don't produce -Inf
 
-// [0.000 0.053 0.105 0.158 0.211 0.263 0.316 0.368 0.421 0.474 0.526 0.579 0.632 0.684 0.737 0.789 0.842 0.895 0.947 1.000, 0.947,]
+func mfccLog(energies [][]float64) [][]float64 {
+	const energyFloor = 1e-10
 
-// Add 20 zeros to the beginning and end of the power spectrum as padding for the kernel
-func mfccWeighSignal(powerSpectrum [][]float64) [][]float64 {
-	paddedSpectrum := make([][]float64, len(powerSpectrum))
-	for i := 0; i < len(powerSpectrum); i++ {
-		temp := make([]float64, len(powerSpectrum[i])+40)
+	for i := 0; i < len(energies); i++ {
+		for j := 0; j < len(energies[i]); j++ {
+			energies[i][j] = math.Log(math.Max(energies[i][j], energyFloor))
+		}
+	}
+	return energies
+}
 
-		// Possible BIG ISSUE Here: The way that I'm copying on the next line may be fundamentally flawed,
-		// but may be resulting in 20 null values at the start of the slice.
+// Orthonormal DCT-II matrix (coefficients x filters), the transform used for MFCCs
+// Only the first coefficients are computed: the higher ones describe fast changes between filter
+// energies, which are not useful for speech.
 
-		copy(temp[20:], powerSpectrum[i])
-		paddedSpectrum[i] = temp
+func mfccDCTMatrix(filters int, coefficients int) [][]float64 {
+	matrix := make([][]float64, coefficients)
+	for k := 0; k < coefficients; k++ {
+		scale := math.Sqrt(2 / float64(filters))
+		if k == 0 {
+			scale = math.Sqrt(1 / float64(filters))
+		}
+		matrix[k] = make([]float64, filters)
+		for n := 0; n < filters; n++ {
+			matrix[k][n] = scale * math.Cos(math.Pi*float64(k)*(float64(n)+0.5)/float64(filters))
+		}
 	}
+	return matrix
+}
 
-	// My understanding is that for the kernel, if it is a kernel of width 40, the actual length is 41 so that the center is 1.0
-	triangularkernel := []float64{0.05, 0.1, 0.15, 0.2, 0.25, 0.3, 0.35, 0.4, 0.45, 0.5, 0.55, 0.6, 0.65, 0.7, 0.75, 0.8, 0.85, 0.9, 0.95, 1.0, 0.95, 0.9, 0.85, 0.8, 0.75, 0.7, 0.65, 0.6, 0.55, 0.50, 0.45, 0.40, 0.35, 0.30, 0.25, 0.20, 0.15, 0.10, 0.05}
+// DCT of the log energies of each frame, by the matrix from mfccDCTMatrix
 
-	weightedOutput := make([][]float64, len(powerSpectrum))
-	for i := 0; i < len(powerSpectrum); i++ { // Index of slices
-		weightedOutput[i] = make([]float64, len(powerSpectrum[i]))
-		for j := 0; j < len(powerSpectrum[i]); j++ { // Index of values
-			for k := 0; k < len(triangularkernel); k++ { // Index of kernel
-				weightedOutput[i][j] += paddedSpectrum[i][j+k] * triangularkernel[k]
+func mfccDCT(logEnergies [][]float64, dctMatrix [][]float64) [][]float64 {
+	dctCoefficients := make([][]float64, len(logEnergies))
+	for i := 0; i < len(logEnergies); i++ {
+		dctCoefficients[i] = make([]float64, len(dctMatrix))
+		for k, row := range dctMatrix {
+			for n, energy := range logEnergies[i] {
+				dctCoefficients[i][k] += row[n] * energy
 			}
 		}
 	}
 
-	for i := 0; i < len(weightedOutput); i++ {
-		for j := 0; j < len(weightedOutput[i]); j++ {
-			weightedOutput[i][j] = math.Log(weightedOutput[i][j])
+	return dctCoefficients
+}
+
+// Cepstral mean (and variance) normalization over the whole utterance
+// A fixed microphone or room multiplies the spectrum by the same filter, which becomes a constant
+// offset after the log and the DCT: subtracting the mean of each coefficient removes it, dividing by
+// the standard deviation also evens out the dynamic range between recordings.
+
+func mfccNormalizeCepstrum(mfcc [][]float64, normalization string) [][]float64 {
+	if normalization == NormalizationNone || len(mfcc) == 0 {
+		return mfcc
+	}
+
+	for j := 0; j < len(mfcc[0]); j++ {
+		mean := 0.0
+		for i := 0; i < len(mfcc); i++ {
+			mean += mfcc[i][j]
+		}
+		mean /= float64(len(mfcc))
+
+		variance := 0.0
+		for i := 0; i < len(mfcc); i++ {
+			mfcc[i][j] -= mean
+			variance += mfcc[i][j] * mfcc[i][j]
+		}
+		variance /= float64(len(mfcc))
+
+		if normalization == NormalizationMeanVariance && variance > 0 {
+			standardDeviation := math.Sqrt(variance)
+			for i := 0; i < len(mfcc); i++ {
+				mfcc[i][j] /= standardDeviation
+			}
 		}
 	}
-	return weightedOutput
+
+	return mfcc
 }
 
-func mfccDCT(weightedOutput [][]float64) [][]float64 {
-	dctCoefficients := make([][]float64, len(weightedOutput))
+// Delta (and delta-delta) coefficients appended after the static ones
+// d[t] = sum(n * (c[t+n] - c[t-n]), n = 1..window) / (2 * sum(n*n, n = 1..window)), frames past the edges repeat the edge frame
+// Delta-deltas are the deltas of the deltas.
 
-	for i := 0; i < len(weightedOutput); i++ {
-		dctCoefficients[i] = make([]float64, len(weightedOutput[i]))
-		dct := fourier.NewDCT(len(weightedOutput[i]))
-		dct.Transform(dctCoefficients[i], weightedOutput[i])
+func mfccAppendDeltas(mfcc [][]float64, order int, window int) [][]float64 {
+	if order == 0 || len(mfcc) == 0 {
+		return mfcc
 	}
 
-	return dctCoefficients
+	features := make([][]float64, len(mfcc))
+	for i := 0; i < len(mfcc); i++ {
+		features[i] = append(make([]float64, 0, len(mfcc[i])*(order+1)), mfcc[i]...)
+	}
+
+	current := mfcc
+	for k := 0; k < order; k++ {
+		current = mfccDeltas(current, window)
+		for i := 0; i < len(features); i++ {
+			features[i] = append(features[i], current[i]...)
+		}
+	}
+
+	return features
 }
 
-// Important notes to revisit
-// Dynamic vs Static Framing
-// Discard half of the FFT? Only compute the first half of the FFT? Only the first half contains unique information?
-// filterbank/more filters? Frequency bins is huh?
+func mfccDeltas(coefficients [][]float64, window int) [][]float64 {
+	denominator := 0.0
+	for n := 1; n <= window; n++ {
+		denominator += float64(2 * n * n)
+	}
+
+	last := len(coefficients) - 1
+	deltas := make([][]float64, len(coefficients))
+	for t := 0; t < len(coefficients); t++ {
+		deltas[t] = make([]float64, len(coefficients[t]))
+		for n := 1; n <= window; n++ {
+			next := coefficients[min(t+n, last)]
+			previous := coefficients[max(t-n, 0)]
+			for j := 0; j < len(deltas[t]); j++ {
+				deltas[t][j] += float64(n) * (next[j] - previous[j])
+			}
+		}
+		for j := 0; j < len(deltas[t]); j++ {
+			deltas[t][j] /= denominator
+		}
+	}
+
+	return deltas
+}
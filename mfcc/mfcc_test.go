@@ -0,0 +1,125 @@
+package mfcc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMelScale(t *testing.T) {
+	// 1000 Hz is about 1000 mel by construction of the scale
+	if mel := hertzToMel(1000); math.Abs(mel-1000) > 0.5 {
+		t.Errorf("1000 Hz is %v mel", mel)
+	}
+	for _, frequency := range []float64{0, 133.33, 1000, 6855.5, 11025} {
+		if got := melToHertz(hertzToMel(frequency)); math.Abs(got-frequency) > 1e-9 {
+			t.Errorf("%v Hz converted back to %v Hz", frequency, got)
+		}
+	}
+}
+
+func TestMelFilterbank(t *testing.T) {
+	const filters, sampleRate, fftSize = 26, 16000.0, 512
+	filterbank := mfccMelFilterbank(filters, 0, 8000, sampleRate, fftSize)
+	if len(filterbank) != filters || len(filterbank[0]) != fftSize/2+1 {
+		t.Fatalf("%d filters of %d bins", len(filterbank), len(filterbank[0]))
+	}
+
+	// Each filter is a single triangle, peaks move up the spectrum and spread out
+	previousPeak, previousWidth := -1, 0
+	for i, filter := range filterbank {
+		peak, first, last := 0, -1, -1
+		for j, weight := range filter {
+			if weight < 0 || weight > 1 {
+				t.Fatalf("filter %d bin %d: weight %v", i, j, weight)
+			}
+			if weight > 0 {
+				if first < 0 {
+					first = j
+				}
+				last = j
+			}
+			if weight > filter[peak] {
+				peak = j
+			}
+		}
+		if peak <= previousPeak || last-first < previousWidth {
+			t.Errorf("filter %d peaks at bin %d over [%d, %d]", i, peak, first, last)
+		}
+		previousPeak, previousWidth = peak, last-first
+	}
+
+	// Neighbouring triangles overlap so that the filters add up to 1 between the first and last peaks
+	center := func(i int) float64 {
+		mel := hertzToMel(8000) * float64(i+1) / float64(filters+1)
+		return melToHertz(mel) / (sampleRate / fftSize)
+	}
+	for j := int(math.Ceil(center(0))); float64(j) <= center(filters-1); j++ {
+		sum := 0.0
+		for _, filter := range filterbank {
+			sum += filter[j]
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("bin %d: filters add up to %v", j, sum)
+		}
+	}
+
+	// The upper frequency is limited to the Nyquist frequency
+	clamped := mfccMelFilterbank(filters, 0, 20000, sampleRate, fftSize)
+	for i := range filterbank {
+		for j := range filterbank[i] {
+			if clamped[i][j] != filterbank[i][j] {
+				t.Fatalf("filter %d bin %d: %v above the Nyquist frequency, %v at it", i, j, clamped[i][j], filterbank[i][j])
+			}
+		}
+	}
+}
+
+func TestDCTMatrix(t *testing.T) {
+	const filters = 26
+	matrix := mfccDCTMatrix(filters, filters)
+
+	// Orthonormal: the rows are unit vectors orthogonal to each other
+	for k := range matrix {
+		for l := range matrix {
+			dot := 0.0
+			for n := 0; n < filters; n++ {
+				dot += matrix[k][n] * matrix[l][n]
+			}
+			want := 0.0
+			if k == l {
+				want = 1
+			}
+			if math.Abs(dot-want) > 1e-9 {
+				t.Fatalf("rows %d and %d: dot product %v", k, l, dot)
+			}
+		}
+	}
+
+	if truncated := mfccDCTMatrix(filters, 13); len(truncated) != 13 || truncated[12][5] != matrix[12][5] {
+		t.Error("fewer coefficients are not the first rows of the matrix")
+	}
+}
+
+func TestDCT(t *testing.T) {
+	// A flat spectrum only has the first coefficient, its mean times the square root of the filter count
+	logEnergies := [][]float64{{2, 2, 2, 2}, {1, -1, 1, -1}}
+	coefficients := mfccDCT(logEnergies, mfccDCTMatrix(4, 4))
+	want := []float64{4, 0, 0, 0}
+	for k := range want {
+		if math.Abs(coefficients[0][k]-want[k]) > 1e-9 {
+			t.Errorf("flat spectrum coefficient %d: got %v, want %v", k, coefficients[0][k], want[k])
+		}
+	}
+	if math.Abs(coefficients[1][0]) > 1e-9 {
+		t.Errorf("alternating spectrum has a mean of %v", coefficients[1][0])
+	}
+
+	// Orthonormal, so the energy of the spectrum is kept
+	energy := 0.0
+	for _, coefficient := range coefficients[1] {
+		energy += coefficient * coefficient
+	}
+	if math.Abs(energy-4) > 1e-9 {
+		t.Errorf("alternating spectrum: energy %v, want 4", energy)
+	}
+}
@@ -0,0 +1,149 @@
+package mfcc
+
+import (
+	"fmt"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+	"gonum.org/v1/gonum/dsp/window"
+)
+
+// Settings of the MFCC pipeline, see DefaultMfccOptions for the default values
+type MfccOptions struct {
+	EmphasisFactor float64 // Pre-emphasis coefficient
+	WindowLength   float64 // Length of a frame, in seconds
+	WindowShift    float64 // Time between the starts of two consecutive frames, in seconds
+	WindowType     string  // Window function applied to each frame, see windowFunctions
+	FilterCount    int     // Number of triangular filters in the mel filterbank
+	LowerFrequency float64 // Lowest frequency covered by the filterbank, in Hz
+	UpperFrequency float64 // Highest frequency covered by the filterbank, in Hz
+	Size           int     // Number of cepstral coefficients kept after the DCT
+	Normalization  string  // Per-utterance cepstral normalization, see the Normalization constants
+	DeltaOrder     int     // 0 for none, 1 to append deltas, 2 to append deltas and delta-deltas
+	DeltaWindow    int     // Number of frames on each side used to compute deltas
+}
+
+const (
+	NormalizationNone         = "none"
+	NormalizationMean         = "mean"          // Cepstral mean normalization (CMN)
+	NormalizationMeanVariance = "mean_variance" // Cepstral mean and variance normalization (CMVN)
+)
+
+// Window functions which can be selected with `mfcc_window_type`
+var windowFunctions = map[string]func([]float64) []float64{
+	"hamming":     window.Hamming,
+	"hann":        window.Hann,
+	"blackman":    window.Blackman,
+	"rectangular": window.Rectangular,
+}
+
+func DefaultMfccOptions() *MfccOptions {
+	return &MfccOptions{
+		EmphasisFactor: 0.95,
+		WindowLength:   0.03,
+		WindowShift:    0.015, // 50% overlap
+		WindowType:     "hamming",
+		FilterCount:    40,
+		LowerFrequency: 133.3333,
+		UpperFrequency: 6855.4976,
+		Size:           13,
+		Normalization:  NormalizationNone,
+		DeltaOrder:     0,
+		DeltaWindow:    2,
+	}
+}
+
+// Reads the MFCC options from the task parameters, using the same keys as aeneas
+// (`mfcc_window_length`, `mfcc_window_shift`, `mfcc_filters`, `mfcc_size`, `mfcc_emphasis_factor`,
+// `mfcc_lower_frequency`, `mfcc_upper_frequency`) plus `mfcc_window_type`, `mfcc_normalization`,
+// `mfcc_delta_order` and `mfcc_delta_window`.
+// Parameters which are not set keep their default value.
+func ParseMfccOptions(parameters *datatypes.Parameters) (*MfccOptions, error) {
+	options := DefaultMfccOptions()
+	var err error
+
+	if options.EmphasisFactor, err = parameters.GetFloat("mfcc_emphasis_factor", options.EmphasisFactor); err != nil {
+		return nil, err
+	}
+	if options.WindowLength, err = parameters.GetFloat("mfcc_window_length", options.WindowLength); err != nil {
+		return nil, err
+	}
+	if options.WindowShift, err = parameters.GetFloat("mfcc_window_shift", options.WindowShift); err != nil {
+		return nil, err
+	}
+	if windowType := parameters.Get("mfcc_window_type"); windowType != "" {
+		options.WindowType = windowType
+	}
+	if options.FilterCount, err = parameters.GetInt("mfcc_filters", options.FilterCount); err != nil {
+		return nil, err
+	}
+	if options.LowerFrequency, err = parameters.GetFloat("mfcc_lower_frequency", options.LowerFrequency); err != nil {
+		return nil, err
+	}
+	if options.UpperFrequency, err = parameters.GetFloat("mfcc_upper_frequency", options.UpperFrequency); err != nil {
+		return nil, err
+	}
+	if options.Size, err = parameters.GetInt("mfcc_size", options.Size); err != nil {
+		return nil, err
+	}
+	if normalization := parameters.Get("mfcc_normalization"); normalization != "" {
+		options.Normalization = normalization
+	}
+	if options.DeltaOrder, err = parameters.GetInt("mfcc_delta_order", options.DeltaOrder); err != nil {
+		return nil, err
+	}
+	if options.DeltaWindow, err = parameters.GetInt("mfcc_delta_window", options.DeltaWindow); err != nil {
+		return nil, err
+	}
+
+	return options, options.validate()
+}
+
+// Samples in a frame and between the starts of two consecutive frames, at sampleRate
+func (options *MfccOptions) frameSamples(sampleRate int) (int, int, error) {
+	frameSize := int(float64(sampleRate) * options.WindowLength) // int: can't have a fraction of a sample
+	frameStep := int(float64(sampleRate) * options.WindowShift)  // int: indexes are whole numbers
+	if frameSize < 1 || frameStep < 1 {
+		return 0, 0, fmt.Errorf("mfcc window shorter than a sample at %d Hz", sampleRate)
+	}
+	return frameSize, frameStep, nil
+}
+
+// Number of frames per second of the MFCC of audio at sampleRate, as Result.FrameRate will return,
+// so that parameters in seconds can be checked before the audio is decoded
+func (options *MfccOptions) FrameRate(sampleRate int) (float64, error) {
+	_, frameStep, err := options.frameSamples(sampleRate)
+	if err != nil {
+		return 0, err
+	}
+	return float64(sampleRate) / float64(frameStep), nil
+}
+
+func (options *MfccOptions) validate() error {
+	if options.WindowLength <= 0 || options.WindowShift <= 0 {
+		return fmt.Errorf("mfcc window length and shift must be positive")
+	}
+	if _, ok := windowFunctions[options.WindowType]; !ok {
+		return fmt.Errorf("unknown mfcc window type %q", options.WindowType)
+	}
+	if options.FilterCount < 2 {
+		return fmt.Errorf("mfcc filter count must be at least 2")
+	}
+	if options.LowerFrequency < 0 || options.UpperFrequency <= options.LowerFrequency {
+		return fmt.Errorf("mfcc upper frequency must be above the lower frequency")
+	}
+	if options.Size <= 0 || options.Size > options.FilterCount {
+		return fmt.Errorf("mfcc size must be between 1 and the number of filters")
+	}
+	switch options.Normalization {
+	case NormalizationNone, NormalizationMean, NormalizationMeanVariance:
+	default:
+		return fmt.Errorf("unknown mfcc normalization %q", options.Normalization)
+	}
+	if options.DeltaOrder < 0 || options.DeltaOrder > 2 {
+		return fmt.Errorf("mfcc delta order must be 0, 1 or 2")
+	}
+	if options.DeltaOrder > 0 && options.DeltaWindow < 1 {
+		return fmt.Errorf("mfcc delta window must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/sillsdev/go-aeneas/boundary"
+	"github.com/sillsdev/go-aeneas/datatypes"
+	"github.com/sillsdev/go-aeneas/dtw"
+	"github.com/sillsdev/go-aeneas/mfcc"
+	"github.com/sillsdev/go-aeneas/vad"
+)
+
+/**
+ * Settings shared by the alignment of every level of multilevel text
+ */
+type childAlignment struct {
+	tpv             *datatypes.TaskProcessVariables
+	inputMfcc       *mfcc.Result
+	mfccOptions     *mfcc.MfccOptions
+	dtwOptions      *dtw.Options
+	boundaryOptions *boundary.Options
+	nonspeech       []vad.Interval
+}
+
+/**
+ * Aligns the children of a phrase inside the part of the recording matched by the phrase
+ *
+ * The children are synthesized, concatenated and aligned at once against the frames of
+ * the parent, like the chapter alignment mode. Their boundaries are adjusted like the ones
+ * of the top level, and their own children are aligned the same way, recursively.
+ */
+func (alignment *childAlignment) align(parent boundary.Fragment, children []*datatypes.Phrase) ([]*datatypes.SyncMapFragment, error) {
+	childMfccs, err := synthesizeMfcc(alignment.tpv, children, alignment.mfccOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	synthesized := make([][]float64, 0)
+	anchors := make([]int, 0, len(children))
+	for _, childMfcc := range childMfccs {
+		anchors = append(anchors, len(synthesized))
+		synthesized = append(synthesized, childMfcc.Coefficients...)
+	}
+
+	result := dtw.RunGlobalDtw(alignment.inputMfcc.Coefficients[parent.Begin:parent.End], synthesized, anchors, alignment.dtwOptions)
+
+	fragments := make([]boundary.Fragment, len(children))
+	for i, child := range children {
+		fragments[i] = boundary.Fragment{
+			Begin:      parent.Begin + result.Segments[i].Start,
+			End:        parent.Begin + result.Segments[i].End,
+			Characters: len([]rune(child.PhraseText)),
+		}
+	}
+	if len(fragments) > 0 {
+		// The children cover the whole of their parent
+		fragments[0].Begin = parent.Begin
+		fragments[len(fragments)-1].End = parent.End
+	}
+	boundary.Adjust(fragments, alignment.nonspeech, alignment.boundaryOptions)
+
+	syncMapFragments := make([]*datatypes.SyncMapFragment, len(children))
+	for i, child := range children {
+		syncMapFragments[i] = &datatypes.SyncMapFragment{
+			Id:    child.PhraseIndex,
+			Begin: alignment.inputMfcc.FrameToTime(fragments[i].Begin),
+			End:   alignment.inputMfcc.FrameToTime(fragments[i].End),
+			Text:  child.PhraseText,
+		}
+		if len(child.Children) > 0 {
+			if syncMapFragments[i].Children, err = alignment.align(fragments[i], child.Children); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return syncMapFragments, nil
+}
+
+/**
+ * Generates the audio of each phrase and computes its MFCC, all phrases at the same time
+ */
+func synthesizeMfcc(tpv *datatypes.TaskProcessVariables, phrases []*datatypes.Phrase, mfccOptions *mfcc.MfccOptions) ([]*mfcc.Result, error) {
+	results := make([]*mfcc.Result, len(phrases))
+	errs := make([]error, len(phrases))
+
+	var wg sync.WaitGroup
+	for i, phrase := range phrases {
+		wg.Add(1)
+		go func(i int, phrase *datatypes.Phrase) {
+			defer wg.Done()
+			path := tpv.GetPhraseFilePath(phrase.PhraseIndex)
+			if err := (*tpv.Generator).GenerateAudioFile(tpv.Parameters, phrase, path); err != nil {
+				errs[i] = err
+				return
+			}
+			results[i], errs[i] = mfcc.GenerateMfcc(path, mfccOptions)
+		}(i, phrase)
+	}
+	wg.Wait()
+
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,58 @@
+package syncmapwriters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+// Audacity keeps label times to the microsecond
+const audacityTimePrecision = 6
+
+// Audacity label track, imported with File > Import > Labels
+type AudacityWriter struct {
+}
+
+// Label of each fragment, id (the default) or text
+func audacityLabelKind(parameters *datatypes.Parameters) (string, error) {
+	labelKind := parameters.Get("os_task_file_audacity_label")
+	if labelKind != "" && labelKind != "id" && labelKind != "text" {
+		return "", fmt.Errorf("parameter os_task_file_audacity_label: %q is neither id nor text", labelKind)
+	}
+	return labelKind, nil
+}
+
+func (aw AudacityWriter) Check(task *datatypes.Task, parameters *datatypes.Parameters) error {
+	_, err := audacityLabelKind(parameters)
+	return err
+}
+
+// One `begin<TAB>end<TAB>label` line per fragment without children. The label is the
+// fragment id, or its text with `os_task_file_audacity_label=text`.
+func (aw AudacityWriter) Write(task *datatypes.Task, parameters *datatypes.Parameters, syncMap *datatypes.SyncMap, output io.Writer) error {
+	labelKind, err := audacityLabelKind(parameters)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(output)
+	for _, fragment := range leafFragments(syncMap.Fragments) {
+		label := fragment.Id
+		if labelKind == "text" {
+			label = annotationText(fragment)
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", datatypes.FormatSeconds(fragment.Begin, audacityTimePrecision),
+			datatypes.FormatSeconds(fragment.End, audacityTimePrecision), label)
+	}
+	return writer.Flush()
+}
+
+func (aw AudacityWriter) GetName() string {
+	return "aud"
+}
+
+func GetAudacityWriter() AudacityWriter {
+	return AudacityWriter{}
+}
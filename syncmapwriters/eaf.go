@@ -0,0 +1,97 @@
+package syncmapwriters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+// MIME types ELAN uses for the linked media, by extension
+var eafMimeTypes = map[string]string{
+	".wav":  "audio/x-wav",
+	".mp3":  "audio/mpeg",
+	".ogg":  "audio/ogg",
+	".flac": "audio/flac",
+}
+
+// ELAN annotation document (EAF 3.0)
+type EafWriter struct {
+}
+
+// Times are always in milliseconds, no parameter is read
+func (ew EafWriter) Check(task *datatypes.Task, parameters *datatypes.Parameters) error {
+	return nil
+}
+
+// One time alignable tier per level of fragments, named "level 1", "level 2"..., linked to
+// the audio file of the task. Times are in milliseconds as required by the format.
+func (ew EafWriter) Write(task *datatypes.Task, parameters *datatypes.Parameters, syncMap *datatypes.SyncMap, output io.Writer) error {
+	writer := bufio.NewWriter(output)
+	fmt.Fprintln(writer, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintf(writer, "<ANNOTATION_DOCUMENT AUTHOR=\"\" DATE=\"%s\" FORMAT=\"3.0\" VERSION=\"3.0\" xmlns:xsi=\"http://www.w3.org/2001/XMLSchema-instance\" xsi:noNamespaceSchemaLocation=\"http://www.mpi.nl/tools/elan/EAFv3.0.xsd\">\n",
+		time.Now().Format(time.RFC3339))
+
+	fmt.Fprintln(writer, ` <HEADER MEDIA_FILE="" TIME_UNITS="milliseconds">`)
+	if task.AudioFilename != "" {
+		mediaPath, err := filepath.Abs(task.AudioFilename)
+		if err != nil {
+			return err
+		}
+		mediaUrl := url.URL{Scheme: "file", Path: filepath.ToSlash(mediaPath)}
+		mimeType, ok := eafMimeTypes[strings.ToLower(filepath.Ext(mediaPath))]
+		if !ok {
+			mimeType = "unknown"
+		}
+		fmt.Fprintf(writer, "  <MEDIA_DESCRIPTOR MEDIA_URL=\"%s\" MIME_TYPE=\"%s\" RELATIVE_MEDIA_URL=\"./%s\"/>\n",
+			escapeXml(mediaUrl.String()), mimeType, escapeXml(url.PathEscape(filepath.Base(mediaPath))))
+	}
+	fmt.Fprintln(writer, ` </HEADER>`)
+
+	// Every begin and end is a time slot, referenced by the annotations
+	levels := fragmentLevels(syncMap.Fragments)
+	fmt.Fprintln(writer, ` <TIME_ORDER>`)
+	slot := 0
+	for _, level := range levels {
+		for _, fragment := range level {
+			for _, t := range []time.Duration{fragment.Begin, fragment.End} {
+				slot++
+				fmt.Fprintf(writer, "  <TIME_SLOT TIME_SLOT_ID=\"ts%d\" TIME_VALUE=\"%d\"/>\n", slot, t.Round(time.Millisecond).Milliseconds())
+			}
+		}
+	}
+	fmt.Fprintln(writer, ` </TIME_ORDER>`)
+
+	slot = 0
+	annotation := 0
+	for i, level := range levels {
+		fmt.Fprintf(writer, " <TIER LINGUISTIC_TYPE_REF=\"default-lt\" TIER_ID=\"level %d\">\n", i+1)
+		for _, fragment := range level {
+			annotation++
+			fmt.Fprintln(writer, `  <ANNOTATION>`)
+			fmt.Fprintf(writer, "   <ALIGNABLE_ANNOTATION ANNOTATION_ID=\"a%d\" TIME_SLOT_REF1=\"ts%d\" TIME_SLOT_REF2=\"ts%d\">\n", annotation, slot+1, slot+2)
+			fmt.Fprintf(writer, "    <ANNOTATION_VALUE>%s</ANNOTATION_VALUE>\n", escapeXml(annotationText(fragment)))
+			fmt.Fprintln(writer, `   </ALIGNABLE_ANNOTATION>`)
+			fmt.Fprintln(writer, `  </ANNOTATION>`)
+			slot += 2
+		}
+		fmt.Fprintln(writer, ` </TIER>`)
+	}
+
+	fmt.Fprintln(writer, ` <LINGUISTIC_TYPE GRAPHIC_REFERENCES="false" LINGUISTIC_TYPE_ID="default-lt" TIME_ALIGNABLE="true"/>`)
+	fmt.Fprintln(writer, `</ANNOTATION_DOCUMENT>`)
+	return writer.Flush()
+}
+
+func (ew EafWriter) GetName() string {
+	return "eaf"
+}
+
+func GetEafWriter() EafWriter {
+	return EafWriter{}
+}
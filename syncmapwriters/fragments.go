@@ -0,0 +1,43 @@
+package syncmapwriters
+
+import (
+	"strings"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+// Fragments without children, in order, children taking the place of their parent
+func leafFragments(fragments []*datatypes.SyncMapFragment) []*datatypes.SyncMapFragment {
+	leaves := make([]*datatypes.SyncMapFragment, 0, len(fragments))
+	for _, fragment := range fragments {
+		if len(fragment.Children) > 0 {
+			leaves = append(leaves, leafFragments(fragment.Children)...)
+		} else {
+			leaves = append(leaves, fragment)
+		}
+	}
+	return leaves
+}
+
+// Fragments grouped by depth: the top level fragments, then all their children, and so on
+func fragmentLevels(fragments []*datatypes.SyncMapFragment) [][]*datatypes.SyncMapFragment {
+	levels := make([][]*datatypes.SyncMapFragment, 0)
+	for len(fragments) > 0 {
+		levels = append(levels, fragments)
+		children := make([]*datatypes.SyncMapFragment, 0)
+		for _, fragment := range fragments {
+			children = append(children, fragment.Children...)
+		}
+		fragments = children
+	}
+	return levels
+}
+
+// Text of a fragment on a single line, or its id when it has no text
+func annotationText(fragment *datatypes.SyncMapFragment) string {
+	text := strings.Join(strings.Fields(fragment.Text), " ")
+	if text == "" {
+		return fragment.Id
+	}
+	return text
+}
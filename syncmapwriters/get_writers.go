@@ -0,0 +1,43 @@
+package syncmapwriters
+
+import (
+	"fmt"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+// Format used when `os_task_file_format` is not set
+const DefaultFormat = "sab"
+
+func GetSyncMapWriters() []datatypes.SyncMapWriter {
+	writers := []datatypes.SyncMapWriter{GetSabWriter(), GetJsonWriter()}
+	for _, writer := range GetSmilWriters() {
+		writers = append(writers, writer)
+	}
+	writers = append(writers, GetSrtWriter(), GetVttWriter(), GetSbvWriter())
+	for _, writer := range GetTtmlWriters() {
+		writers = append(writers, writer)
+	}
+	for _, writer := range GetTextGridWriters() {
+		writers = append(writers, writer)
+	}
+	writers = append(writers, GetEafWriter())
+	for _, writer := range GetTabularWriters() {
+		writers = append(writers, writer)
+	}
+	writers = append(writers, GetXmlWriter(), GetAudacityWriter())
+	return writers
+}
+
+// Looks up a writer by the name used in `os_task_file_format`
+func GetSyncMapWriter(format string) (datatypes.SyncMapWriter, error) {
+	if format == "" {
+		format = DefaultFormat
+	}
+	for _, writer := range GetSyncMapWriters() {
+		if writer.GetName() == format {
+			return writer, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
@@ -0,0 +1,67 @@
+package syncmapwriters
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+type JsonWriter struct {
+}
+
+// Fragment as written by aeneas, keys in the same (alphabetical) order and times as strings
+type jsonFragment struct {
+	Begin    string          `json:"begin"`
+	Children []*jsonFragment `json:"children"`
+	End      string          `json:"end"`
+	Id       string          `json:"id"`
+	Language string          `json:"language"`
+	Lines    []string        `json:"lines"`
+}
+
+func (jw JsonWriter) Check(task *datatypes.Task, parameters *datatypes.Parameters) error {
+	_, err := parameters.GetTimePrecision()
+	return err
+}
+
+// aeneas JSON sync map: `{"fragments": [...]}`, the language is the `task_language` parameter
+func (jw JsonWriter) Write(task *datatypes.Task, parameters *datatypes.Parameters, syncMap *datatypes.SyncMap, output io.Writer) error {
+	precision, err := parameters.GetTimePrecision()
+	if err != nil {
+		return err
+	}
+
+	document := struct {
+		Fragments []*jsonFragment `json:"fragments"`
+	}{jsonFragments(syncMap.Fragments, parameters.Get("task_language"), precision)}
+
+	encoder := json.NewEncoder(output)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", " ")
+	return encoder.Encode(document)
+}
+
+func jsonFragments(fragments []*datatypes.SyncMapFragment, language string, precision int) []*jsonFragment {
+	result := make([]*jsonFragment, 0, len(fragments))
+	for _, fragment := range fragments {
+		result = append(result, &jsonFragment{
+			Begin:    datatypes.FormatSeconds(fragment.Begin, precision),
+			Children: jsonFragments(fragment.Children, language, precision),
+			End:      datatypes.FormatSeconds(fragment.End, precision),
+			Id:       fragment.Id,
+			Language: language,
+			Lines:    strings.Split(fragment.Text, "\n"),
+		})
+	}
+	return result
+}
+
+func (jw JsonWriter) GetName() string {
+	return "json"
+}
+
+func GetJsonWriter() JsonWriter {
+	return JsonWriter{}
+}
@@ -0,0 +1,69 @@
+package syncmapwriters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+type SabWriter struct {
+}
+
+// Punctuation splitting the text into units of each level, none for verses
+var sabSeparators = map[string]string{
+	datatypes.LevelPhrase:   ". ? ! : ; ,",
+	datatypes.LevelSentence: ". ? !",
+	datatypes.LevelVerse:    "",
+}
+
+// The book and chapter of the task are written in the header, so they are required
+func (sw SabWriter) Check(task *datatypes.Task, parameters *datatypes.Parameters) error {
+	if _, err := parameters.GetTimePrecision(); err != nil {
+		return err
+	}
+	if err := task.Validate(); err != nil {
+		return err
+	}
+	if task.Book == "" || task.Chapter == 0 {
+		return fmt.Errorf("the %s format requires the book and chapter of the task", sw.GetName())
+	}
+	return nil
+}
+
+// Phrase timing file of Scripture App Builder: a header with the book, chapter and level of
+// the task, then one `begin<TAB>end<TAB>id` line per fragment
+func (sw SabWriter) Write(task *datatypes.Task, parameters *datatypes.Parameters, syncMap *datatypes.SyncMap, output io.Writer) error {
+	if err := sw.Check(task, parameters); err != nil {
+		return err
+	}
+	precision, err := parameters.GetTimePrecision()
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(output)
+	fmt.Fprintf(writer, "\\id %s\n", task.Book)
+	fmt.Fprintf(writer, "\\c %d\n", task.Chapter)
+	fmt.Fprintf(writer, "\\level %s\n", task.GetLevel())
+	if separators := sabSeparators[task.GetLevel()]; separators != "" {
+		fmt.Fprintf(writer, "\\separators %s\n", separators)
+	}
+
+	for _, fragment := range syncMap.Fragments {
+		begin := datatypes.FormatSeconds(fragment.Begin, precision)
+		end := datatypes.FormatSeconds(fragment.End, precision)
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", begin, end, fragment.Id)
+	}
+
+	return writer.Flush()
+}
+
+func (sw SabWriter) GetName() string {
+	return "sab"
+}
+
+func GetSabWriter() SabWriter {
+	return SabWriter{}
+}
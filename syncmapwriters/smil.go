@@ -0,0 +1,122 @@
+package syncmapwriters
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+// SMIL 3.0 for EPUB3 Media Overlays, clock values written as hh:mm:ss.mmm (smil, smilh)
+// or as seconds (smilm), like aeneas
+type SmilWriter struct {
+	name         string
+	clockSeconds bool
+}
+
+// Page and audio references of the fragments, both required
+func (sw SmilWriter) refs(parameters *datatypes.Parameters) (string, string, error) {
+	pageRef := parameters.Get("os_task_file_smil_page_ref")
+	audioRef := parameters.Get("os_task_file_smil_audio_ref")
+	if pageRef == "" || audioRef == "" {
+		return "", "", fmt.Errorf("the %s format requires os_task_file_smil_page_ref and os_task_file_smil_audio_ref", sw.name)
+	}
+	return pageRef, audioRef, nil
+}
+
+func (sw SmilWriter) Check(task *datatypes.Task, parameters *datatypes.Parameters) error {
+	if _, err := parameters.GetTimePrecision(); err != nil {
+		return err
+	}
+	_, _, err := sw.refs(parameters)
+	return err
+}
+
+// Writes one `<par>` per fragment, referencing `os_task_file_smil_page_ref#id` and the clip
+// of `os_task_file_smil_audio_ref`. Fragments with children become a `<seq>` of their children.
+func (sw SmilWriter) Write(task *datatypes.Task, parameters *datatypes.Parameters, syncMap *datatypes.SyncMap, output io.Writer) error {
+	precision, err := parameters.GetTimePrecision()
+	if err != nil {
+		return err
+	}
+	pageRef, audioRef, err := sw.refs(parameters)
+	if err != nil {
+		return err
+	}
+
+	smil := &smilDocument{
+		writer:   bufio.NewWriter(output),
+		pageRef:  pageRef,
+		audioRef: audioRef,
+		formatTime: func(t time.Duration) string {
+			if sw.clockSeconds {
+				return datatypes.FormatSeconds(t, precision)
+			}
+			return datatypes.FormatClock(t, precision)
+		},
+	}
+
+	smil.line(0, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`)
+	smil.line(0, `<smil xmlns="http://www.w3.org/ns/SMIL" xmlns:epub="http://www.idpf.org/2007/ops" version="3.0">`)
+	smil.line(1, `<body>`)
+	smil.seq(2, pageRef, syncMap.Fragments)
+	smil.line(1, `</body>`)
+	smil.line(0, `</smil>`)
+
+	return smil.writer.Flush()
+}
+
+type smilDocument struct {
+	writer     *bufio.Writer
+	pageRef    string
+	audioRef   string
+	formatTime func(t time.Duration) string
+	seqCount   int
+	parCount   int
+}
+
+func (smil *smilDocument) line(depth int, text string) {
+	smil.writer.WriteString(strings.Repeat(" ", depth))
+	smil.writer.WriteString(text)
+	smil.writer.WriteString("\n")
+}
+
+func (smil *smilDocument) seq(depth int, textRef string, fragments []*datatypes.SyncMapFragment) {
+	smil.seqCount++
+	smil.line(depth, fmt.Sprintf(`<seq id="seq%06d" epub:textref="%s">`, smil.seqCount, escapeXml(textRef)))
+	for _, fragment := range fragments {
+		if len(fragment.Children) > 0 {
+			smil.seq(depth+1, smil.pageRef+"#"+fragment.Id, fragment.Children)
+		} else {
+			smil.par(depth+1, fragment)
+		}
+	}
+	smil.line(depth, `</seq>`)
+}
+
+func (smil *smilDocument) par(depth int, fragment *datatypes.SyncMapFragment) {
+	smil.parCount++
+	smil.line(depth, fmt.Sprintf(`<par id="par%06d">`, smil.parCount))
+	smil.line(depth+1, fmt.Sprintf(`<text src="%s"/>`, escapeXml(smil.pageRef+"#"+fragment.Id)))
+	smil.line(depth+1, fmt.Sprintf(`<audio clipBegin="%s" clipEnd="%s" src="%s"/>`,
+		smil.formatTime(fragment.Begin), smil.formatTime(fragment.End), escapeXml(smil.audioRef)))
+	smil.line(depth, `</par>`)
+}
+
+func escapeXml(text string) string {
+	var builder strings.Builder
+	xml.EscapeText(&builder, []byte(text))
+	return builder.String()
+}
+
+func (sw SmilWriter) GetName() string {
+	return sw.name
+}
+
+func GetSmilWriters() []SmilWriter {
+	return []SmilWriter{{"smil", false}, {"smilh", false}, {"smilm", true}}
+}
@@ -0,0 +1,168 @@
+package syncmapwriters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+// Subtitle formats always use milliseconds
+const subtitleTimePrecision = 3
+
+// Checks `os_task_file_subtitle_line_length`, shared by SRT, WebVTT and SBV
+func checkSubtitleLineLength(parameters *datatypes.Parameters) error {
+	_, err := parameters.GetInt("os_task_file_subtitle_line_length", 0)
+	return err
+}
+
+// Lines of a cue: the lines of the text without the empty ones (which would end the cue),
+// wrapped at `os_task_file_subtitle_line_length` characters when it is set
+func subtitleLines(parameters *datatypes.Parameters, text string) ([]string, error) {
+	lineLength, err := parameters.GetInt("os_task_file_subtitle_line_length", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]string, 0)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if lineLength <= 0 {
+			lines = append(lines, line)
+		} else {
+			lines = append(lines, wrapLine(line, lineLength)...)
+		}
+	}
+	return lines, nil
+}
+
+// Splits a line between words so that each part is at most lineLength characters,
+// unless a single word is longer
+func wrapLine(line string, lineLength int) []string {
+	lines := make([]string, 0)
+	current := ""
+	for _, word := range strings.Fields(line) {
+		if current != "" && len([]rune(current))+1+len([]rune(word)) > lineLength {
+			lines = append(lines, current)
+			current = ""
+		}
+		if current != "" {
+			current += " "
+		}
+		current += word
+	}
+	return append(lines, current)
+}
+
+// Writes numbered or unnumbered cues separated by blank lines, shared by SRT, WebVTT and SBV
+func writeCues(parameters *datatypes.Parameters, syncMap *datatypes.SyncMap, writer *bufio.Writer, numbered bool, timing func(fragment *datatypes.SyncMapFragment) string) error {
+	for i, fragment := range leafFragments(syncMap.Fragments) {
+		lines, err := subtitleLines(parameters, fragment.Text)
+		if err != nil {
+			return err
+		}
+
+		if numbered {
+			fmt.Fprintf(writer, "%d\n", i+1)
+		}
+		fmt.Fprintln(writer, timing(fragment))
+		for _, line := range lines {
+			fmt.Fprintln(writer, line)
+		}
+		fmt.Fprintln(writer)
+	}
+	return writer.Flush()
+}
+
+type SrtWriter struct {
+}
+
+func (sw SrtWriter) Check(task *datatypes.Task, parameters *datatypes.Parameters) error {
+	return checkSubtitleLineLength(parameters)
+}
+
+// SubRip: numbered cues timed `hh:mm:ss,mmm --> hh:mm:ss,mmm`
+func (sw SrtWriter) Write(task *datatypes.Task, parameters *datatypes.Parameters, syncMap *datatypes.SyncMap, output io.Writer) error {
+	srtTime := func(t time.Duration) string {
+		return strings.Replace(datatypes.FormatClock(t, subtitleTimePrecision), ".", ",", 1)
+	}
+	return writeCues(parameters, syncMap, bufio.NewWriter(output), true, func(fragment *datatypes.SyncMapFragment) string {
+		return srtTime(fragment.Begin) + " --> " + srtTime(fragment.End)
+	})
+}
+
+func (sw SrtWriter) GetName() string {
+	return "srt"
+}
+
+func GetSrtWriter() SrtWriter {
+	return SrtWriter{}
+}
+
+type VttWriter struct {
+}
+
+func (vw VttWriter) Check(task *datatypes.Task, parameters *datatypes.Parameters) error {
+	return checkSubtitleLineLength(parameters)
+}
+
+// WebVTT: `WEBVTT` header then numbered cues timed `hh:mm:ss.mmm --> hh:mm:ss.mmm`, followed
+// by the cue settings of `os_task_file_vtt_cue_settings` (e.g. `align:start line:90%`) when set
+func (vw VttWriter) Write(task *datatypes.Task, parameters *datatypes.Parameters, syncMap *datatypes.SyncMap, output io.Writer) error {
+	cueSettings := strings.TrimSpace(parameters.Get("os_task_file_vtt_cue_settings"))
+	if cueSettings != "" {
+		cueSettings = " " + cueSettings
+	}
+
+	writer := bufio.NewWriter(output)
+	fmt.Fprint(writer, "WEBVTT\n\n")
+	return writeCues(parameters, syncMap, writer, true, func(fragment *datatypes.SyncMapFragment) string {
+		begin := datatypes.FormatClock(fragment.Begin, subtitleTimePrecision)
+		end := datatypes.FormatClock(fragment.End, subtitleTimePrecision)
+		return begin + " --> " + end + cueSettings
+	})
+}
+
+func (vw VttWriter) GetName() string {
+	return "vtt"
+}
+
+func GetVttWriter() VttWriter {
+	return VttWriter{}
+}
+
+type SbvWriter struct {
+}
+
+func (sw SbvWriter) Check(task *datatypes.Task, parameters *datatypes.Parameters) error {
+	return checkSubtitleLineLength(parameters)
+}
+
+// YouTube SubViewer: unnumbered cues timed `h:mm:ss.mmm,h:mm:ss.mmm`
+func (sw SbvWriter) Write(task *datatypes.Task, parameters *datatypes.Parameters, syncMap *datatypes.SyncMap, output io.Writer) error {
+	sbvTime := func(t time.Duration) string {
+		// Hours are not zero padded
+		clock := datatypes.FormatClock(t, subtitleTimePrecision)
+		if strings.HasPrefix(clock, "0") && clock[2] == ':' {
+			clock = clock[1:]
+		}
+		return clock
+	}
+	return writeCues(parameters, syncMap, bufio.NewWriter(output), false, func(fragment *datatypes.SyncMapFragment) string {
+		return sbvTime(fragment.Begin) + "," + sbvTime(fragment.End)
+	})
+}
+
+func (sw SbvWriter) GetName() string {
+	return "sbv"
+}
+
+func GetSbvWriter() SbvWriter {
+	return SbvWriter{}
+}
@@ -0,0 +1,101 @@
+package syncmapwriters
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+// Columns written when `os_task_file_columns` is not set
+const defaultColumns = "id,begin,end,text"
+
+// Comma or tab separated values, one row per fragment without children
+type TabularWriter struct {
+	name      string
+	separator rune
+}
+
+// Columns of `os_task_file_columns`, or the default ones when it is not set
+func tabularColumns(parameters *datatypes.Parameters) ([]string, error) {
+	columnList := parameters.Get("os_task_file_columns")
+	if columnList == "" {
+		columnList = defaultColumns
+	}
+	columns := strings.Split(columnList, ",")
+	for i, column := range columns {
+		columns[i] = strings.TrimSpace(column)
+		switch columns[i] {
+		case "id", "begin", "end", "duration", "text":
+		default:
+			return nil, fmt.Errorf("parameter os_task_file_columns: unknown column %q", column)
+		}
+	}
+	return columns, nil
+}
+
+func (tw TabularWriter) Check(task *datatypes.Task, parameters *datatypes.Parameters) error {
+	if _, err := parameters.GetTimePrecision(); err != nil {
+		return err
+	}
+	_, err := tabularColumns(parameters)
+	return err
+}
+
+// Columns are chosen with `os_task_file_columns`, a comma separated list of id, begin, end,
+// duration and text. A header row with the column names is written when `os_task_file_header`
+// is true. Fields are quoted when needed.
+func (tw TabularWriter) Write(task *datatypes.Task, parameters *datatypes.Parameters, syncMap *datatypes.SyncMap, output io.Writer) error {
+	precision, err := parameters.GetTimePrecision()
+	if err != nil {
+		return err
+	}
+
+	columns, err := tabularColumns(parameters)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(output)
+	writer.Comma = tw.separator
+
+	if parameters.Get("os_task_file_header") == "true" {
+		if err := writer.Write(columns); err != nil {
+			return err
+		}
+	}
+
+	for _, fragment := range leafFragments(syncMap.Fragments) {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			switch column {
+			case "id":
+				row[i] = fragment.Id
+			case "begin":
+				row[i] = datatypes.FormatSeconds(fragment.Begin, precision)
+			case "end":
+				row[i] = datatypes.FormatSeconds(fragment.End, precision)
+			case "duration":
+				row[i] = datatypes.FormatSeconds(fragment.End-fragment.Begin, precision)
+			case "text":
+				row[i] = fragment.Text
+			}
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (tw TabularWriter) GetName() string {
+	return tw.name
+}
+
+func GetTabularWriters() []TabularWriter {
+	return []TabularWriter{{"csv", ','}, {"tsv", '\t'}}
+}
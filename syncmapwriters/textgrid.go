@@ -0,0 +1,126 @@
+package syncmapwriters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+// Praat TextGrid, in the long (textgrid) or short (textgrid_short) text format
+type TextGridWriter struct {
+	name  string
+	short bool
+}
+
+type textGridInterval struct {
+	begin time.Duration
+	end   time.Duration
+	text  string
+}
+
+func (tw TextGridWriter) Check(task *datatypes.Task, parameters *datatypes.Parameters) error {
+	_, err := parameters.GetTimePrecision()
+	return err
+}
+
+// One interval tier per level of fragments, named "level 1", "level 2"... Praat needs the
+// intervals of a tier to cover the whole audio, so gaps between fragments are empty intervals.
+func (tw TextGridWriter) Write(task *datatypes.Task, parameters *datatypes.Parameters, syncMap *datatypes.SyncMap, output io.Writer) error {
+	precision, err := parameters.GetTimePrecision()
+	if err != nil {
+		return err
+	}
+	levels := fragmentLevels(syncMap.Fragments)
+
+	end := syncMap.Duration
+	for _, level := range levels {
+		end = max(end, level[len(level)-1].End)
+	}
+	seconds := func(t time.Duration) string {
+		return datatypes.FormatSeconds(t, precision)
+	}
+
+	writer := bufio.NewWriter(output)
+	// Lines of the long format are `label = value`, the short format only keeps the values
+	field := func(indent int, label string, value string) {
+		if tw.short {
+			fmt.Fprintln(writer, value)
+		} else {
+			fmt.Fprintf(writer, "%s%s = %s\n", strings.Repeat("    ", indent), label, value)
+		}
+	}
+
+	fmt.Fprintln(writer, `File type = "ooTextFile"`)
+	fmt.Fprintln(writer, `Object class = "TextGrid"`)
+	fmt.Fprintln(writer)
+	field(0, "xmin", seconds(0))
+	field(0, "xmax", seconds(end))
+	if tw.short {
+		fmt.Fprintln(writer, "<exists>")
+	} else {
+		fmt.Fprintln(writer, "tiers? <exists>")
+	}
+	field(0, "size", fmt.Sprint(len(levels)))
+	if !tw.short {
+		fmt.Fprintln(writer, "item []:")
+	}
+
+	for i, level := range levels {
+		intervals := textGridIntervals(level, end)
+		if !tw.short {
+			fmt.Fprintf(writer, "    item [%d]:\n", i+1)
+		}
+		field(2, "class", `"IntervalTier"`)
+		field(2, "name", textGridString(fmt.Sprintf("level %d", i+1)))
+		field(2, "xmin", seconds(0))
+		field(2, "xmax", seconds(end))
+		field(2, "intervals: size", fmt.Sprint(len(intervals)))
+		for j, interval := range intervals {
+			if !tw.short {
+				fmt.Fprintf(writer, "        intervals [%d]:\n", j+1)
+			}
+			field(3, "xmin", seconds(interval.begin))
+			field(3, "xmax", seconds(interval.end))
+			field(3, "text", textGridString(interval.text))
+		}
+	}
+
+	return writer.Flush()
+}
+
+// Intervals covering [0, end) without overlapping, empty ones filling the gaps
+func textGridIntervals(fragments []*datatypes.SyncMapFragment, end time.Duration) []textGridInterval {
+	intervals := make([]textGridInterval, 0, len(fragments))
+	previousEnd := time.Duration(0)
+	for _, fragment := range fragments {
+		begin := max(fragment.Begin, previousEnd)
+		if begin > previousEnd {
+			intervals = append(intervals, textGridInterval{previousEnd, begin, ""})
+		}
+		if fragment.End > begin {
+			intervals = append(intervals, textGridInterval{begin, fragment.End, annotationText(fragment)})
+			previousEnd = fragment.End
+		}
+	}
+	if end > previousEnd {
+		intervals = append(intervals, textGridInterval{previousEnd, end, ""})
+	}
+	return intervals
+}
+
+// Quoted string, quotes being doubled
+func textGridString(text string) string {
+	return `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+}
+
+func (tw TextGridWriter) GetName() string {
+	return tw.name
+}
+
+func GetTextGridWriters() []TextGridWriter {
+	return []TextGridWriter{{"textgrid", false}, {"textgrid_short", true}}
+}
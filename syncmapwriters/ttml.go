@@ -0,0 +1,69 @@
+package syncmapwriters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+// Timed Text Markup Language, also known as DFXP
+type TtmlWriter struct {
+	name string
+}
+
+func (tw TtmlWriter) Check(task *datatypes.Task, parameters *datatypes.Parameters) error {
+	_, err := parameters.GetTimePrecision()
+	return err
+}
+
+// One `<p>` per fragment without children inside a single `<div>`, lines separated by `<br/>`.
+// The document language is the `task_language` parameter.
+func (tw TtmlWriter) Write(task *datatypes.Task, parameters *datatypes.Parameters, syncMap *datatypes.SyncMap, output io.Writer) error {
+	precision, err := parameters.GetTimePrecision()
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(output)
+	fmt.Fprintln(writer, `<?xml version="1.0" encoding="UTF-8"?>`)
+	if language := parameters.Get("task_language"); language != "" {
+		fmt.Fprintf(writer, "<tt xmlns=\"http://www.w3.org/ns/ttml\" xml:lang=\"%s\">\n", escapeXml(language))
+	} else {
+		fmt.Fprintln(writer, `<tt xmlns="http://www.w3.org/ns/ttml">`)
+	}
+	fmt.Fprintln(writer, " <body>")
+	fmt.Fprintln(writer, "  <div>")
+
+	for _, fragment := range leafFragments(syncMap.Fragments) {
+		lines, err := subtitleLines(parameters, fragment.Text)
+		if err != nil {
+			return err
+		}
+		for i := range lines {
+			lines[i] = escapeXml(lines[i])
+		}
+
+		fmt.Fprintf(writer, "   <p xml:id=\"%s\" begin=\"%s\" end=\"%s\">\n", escapeXml(fragment.Id),
+			datatypes.FormatClock(fragment.Begin, precision), datatypes.FormatClock(fragment.End, precision))
+		if len(lines) > 0 {
+			fmt.Fprintf(writer, "    %s\n", strings.Join(lines, "<br/>\n    "))
+		}
+		fmt.Fprintln(writer, "   </p>")
+	}
+
+	fmt.Fprintln(writer, "  </div>")
+	fmt.Fprintln(writer, " </body>")
+	fmt.Fprintln(writer, "</tt>")
+	return writer.Flush()
+}
+
+func (tw TtmlWriter) GetName() string {
+	return tw.name
+}
+
+func GetTtmlWriters() []TtmlWriter {
+	return []TtmlWriter{{"ttml"}, {"dfxp"}}
+}
@@ -0,0 +1,386 @@
+package syncmapwriters
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+var testTask = &datatypes.Task{Book: "MAT", Chapter: 1, AudioFilename: "chapter.mp3", PhraseFilename: "chapter.txt"}
+
+func testSyncMap() *datatypes.SyncMap {
+	return &datatypes.SyncMap{
+		Duration: 3 * time.Second,
+		Fragments: []*datatypes.SyncMapFragment{
+			{Id: "f000001", Begin: 0, End: 1200 * time.Millisecond, Text: "Hello world"},
+			{Id: "f000002", Begin: 1200 * time.Millisecond, End: 2500 * time.Millisecond, Text: "Good bye"},
+		},
+	}
+}
+
+// A paragraph with its two sentences as children
+func testMultilevelSyncMap() *datatypes.SyncMap {
+	syncMap := testSyncMap()
+	for _, sentence := range syncMap.Fragments {
+		sentence.Id = "p000001" + strings.Replace(sentence.Id, "f", "s", 1)
+	}
+	paragraph := &datatypes.SyncMapFragment{
+		Id:       "p000001",
+		End:      2500 * time.Millisecond,
+		Text:     "Hello world\nGood bye",
+		Children: syncMap.Fragments,
+	}
+	syncMap.Fragments = []*datatypes.SyncMapFragment{paragraph}
+	return syncMap
+}
+
+func write(t *testing.T, format string, parameters string, syncMap *datatypes.SyncMap) string {
+	t.Helper()
+	writer, err := GetSyncMapWriter(format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	if err := writer.Write(testTask, datatypes.ParseParameters(parameters), syncMap, &output); err != nil {
+		t.Fatalf("%s: %v", format, err)
+	}
+	return output.String()
+}
+
+func TestGetSyncMapWriter(t *testing.T) {
+	names := make(map[string]bool)
+	for _, writer := range GetSyncMapWriters() {
+		if names[writer.GetName()] {
+			t.Errorf("format %q registered twice", writer.GetName())
+		}
+		names[writer.GetName()] = true
+	}
+
+	if writer, err := GetSyncMapWriter(""); err != nil || writer.GetName() != DefaultFormat {
+		t.Errorf("default format: got %v, %v", writer, err)
+	}
+	if _, err := GetSyncMapWriter("docx"); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
+
+// Whatever Check accepts, Write accepts too, and the other way around
+func TestCheck(t *testing.T) {
+	otherTask := &datatypes.Task{AudioFilename: "chapter.mp3", PhraseFilename: "chapter.txt"}
+	smilRefs := "|os_task_file_smil_page_ref=chapter.xhtml|os_task_file_smil_audio_ref=chapter.mp3"
+
+	tests := []struct {
+		format     string
+		task       *datatypes.Task
+		parameters string
+		valid      bool
+	}{
+		{"json", otherTask, "task_language=eng", true},
+		{"json", otherTask, "os_task_file_time_precision=-1", false},
+		{"sab", testTask, "task_language=eng", true},
+		{"sab", otherTask, "task_language=eng", false},
+		{"sab", &datatypes.Task{Book: "XYZ", Chapter: 1}, "task_language=eng", false},
+		{"smil", otherTask, smilRefs[1:], true},
+		{"smil", otherTask, "os_task_file_smil_page_ref=chapter.xhtml", false},
+		{"smilm", otherTask, "os_task_file_time_precision=x" + smilRefs, false},
+		{"srt", otherTask, "os_task_file_subtitle_line_length=42", true},
+		{"vtt", otherTask, "os_task_file_subtitle_line_length=wide", false},
+		{"sbv", otherTask, "os_task_file_subtitle_line_length=1.5", false},
+		{"ttml", otherTask, "os_task_file_time_precision=x", false},
+		{"textgrid", otherTask, "os_task_file_time_precision=x", false},
+		{"eaf", otherTask, "os_task_file_time_precision=x", true},
+		{"csv", otherTask, "os_task_file_columns=begin, end", true},
+		{"tsv", otherTask, "os_task_file_columns=begin,speaker", false},
+		{"aud", otherTask, "os_task_file_audacity_label=text", true},
+		{"aud", otherTask, "os_task_file_audacity_label=number", false},
+		{"xml", otherTask, "os_task_file_time_precision=x", false},
+	}
+	for _, test := range tests {
+		writer, err := GetSyncMapWriter(test.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parameters := datatypes.ParseParameters(test.parameters)
+		checkErr := writer.Check(test.task, parameters)
+		writeErr := writer.Write(test.task, parameters, testSyncMap(), &bytes.Buffer{})
+		if (checkErr == nil) != test.valid || (writeErr == nil) != test.valid {
+			t.Errorf("%s %s: Check %v, Write %v", test.format, test.parameters, checkErr, writeErr)
+		}
+	}
+}
+
+func TestSab(t *testing.T) {
+	fragments := "0.000\t1.200\tf000001\n1.200\t2.500\tf000002\n"
+	tests := []struct {
+		task *datatypes.Task
+		want string
+	}{
+		{testTask, "\\id MAT\n\\c 1\n\\level phrase\n\\separators . ? ! : ; ,\n" + fragments},
+		{&datatypes.Task{Book: "GEN", Chapter: 50, Level: datatypes.LevelSentence}, "\\id GEN\n\\c 50\n\\level sentence\n\\separators . ? !\n" + fragments},
+		{&datatypes.Task{Book: "PSA", Chapter: 119, Level: datatypes.LevelVerse}, "\\id PSA\n\\c 119\n\\level verse\n" + fragments},
+	}
+	writer := GetSabWriter()
+	for _, test := range tests {
+		var output bytes.Buffer
+		if err := writer.Write(test.task, datatypes.ParseParameters("task_language=eng"), testSyncMap(), &output); err != nil {
+			t.Fatal(err)
+		}
+		if got := output.String(); got != test.want {
+			t.Errorf("got\n%s\nwant\n%s", got, test.want)
+		}
+	}
+
+	for _, task := range []*datatypes.Task{{Chapter: 1}, {Book: "MAT"}, {Book: "MAT", Chapter: 1, Level: "word"}} {
+		if err := writer.Check(task, datatypes.ParseParameters("task_language=eng")); err == nil {
+			t.Errorf("%+v accepted", task)
+		}
+	}
+}
+
+func TestJson(t *testing.T) {
+	want := `{
+ "fragments": [
+  {
+   "begin": "0.00",
+   "children": [],
+   "end": "1.20",
+   "id": "f000001",
+   "language": "eng",
+   "lines": [
+    "Hello world"
+   ]
+  },
+  {
+   "begin": "1.20",
+   "children": [],
+   "end": "2.50",
+   "id": "f000002",
+   "language": "eng",
+   "lines": [
+    "Good bye"
+   ]
+  }
+ ]
+}
+`
+	if got := write(t, "json", "task_language=eng|os_task_file_time_precision=2", testSyncMap()); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	got := write(t, "json", "task_language=eng", testMultilevelSyncMap())
+	for _, want := range []string{`"id": "p000001"`, `"id": "p000001s000002"`, "\"lines\": [\n    \"Hello world\",\n    \"Good bye\"\n   ]"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%s missing from\n%s", want, got)
+		}
+	}
+}
+
+func TestSmil(t *testing.T) {
+	parameters := "os_task_file_smil_page_ref=chapter.xhtml|os_task_file_smil_audio_ref=chapter.mp3"
+	want := `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<smil xmlns="http://www.w3.org/ns/SMIL" xmlns:epub="http://www.idpf.org/2007/ops" version="3.0">
+ <body>
+  <seq id="seq000001" epub:textref="chapter.xhtml">
+   <par id="par000001">
+    <text src="chapter.xhtml#f000001"/>
+    <audio clipBegin="00:00:00.000" clipEnd="00:00:01.200" src="chapter.mp3"/>
+   </par>
+   <par id="par000002">
+    <text src="chapter.xhtml#f000002"/>
+    <audio clipBegin="00:00:01.200" clipEnd="00:00:02.500" src="chapter.mp3"/>
+   </par>
+  </seq>
+ </body>
+</smil>
+`
+	if got := write(t, "smil", parameters, testSyncMap()); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{"smilh", []string{
+			`<seq id="seq000002" epub:textref="chapter.xhtml#p000001">`,
+			`<text src="chapter.xhtml#p000001s000002"/>`,
+			`<audio clipBegin="00:00:01.200" clipEnd="00:00:02.500" src="chapter.mp3"/>`,
+		}},
+		{"smilm", []string{`<audio clipBegin="1.200" clipEnd="2.500" src="chapter.mp3"/>`}},
+	}
+	for _, test := range tests {
+		got := write(t, test.format, parameters, testMultilevelSyncMap())
+		for _, want := range test.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: %s missing from\n%s", test.format, want, got)
+			}
+		}
+	}
+}
+
+func TestSubtitles(t *testing.T) {
+	tests := []struct {
+		format     string
+		parameters string
+		syncMap    *datatypes.SyncMap
+		want       string
+	}{
+		{"srt", "task_language=eng", testSyncMap(), "1\n00:00:00,000 --> 00:00:01,200\nHello world\n\n2\n00:00:01,200 --> 00:00:02,500\nGood bye\n\n"},
+		{"vtt", "task_language=eng", testSyncMap(), "WEBVTT\n\n1\n00:00:00.000 --> 00:00:01.200\nHello world\n\n2\n00:00:01.200 --> 00:00:02.500\nGood bye\n\n"},
+		{"vtt", "os_task_file_vtt_cue_settings=align:start line:90%", testSyncMap(), "WEBVTT\n\n1\n00:00:00.000 --> 00:00:01.200 align:start line:90%\nHello world\n\n2\n00:00:01.200 --> 00:00:02.500 align:start line:90%\nGood bye\n\n"},
+		{"sbv", "task_language=eng", testSyncMap(), "0:00:00.000,0:00:01.200\nHello world\n\n0:00:01.200,0:00:02.500\nGood bye\n\n"},
+		{"srt", "os_task_file_subtitle_line_length=6", testSyncMap(), "1\n00:00:00,000 --> 00:00:01,200\nHello\nworld\n\n2\n00:00:01,200 --> 00:00:02,500\nGood\nbye\n\n"},
+		// The children replace their parent
+		{"sbv", "task_language=eng", testMultilevelSyncMap(), "0:00:00.000,0:00:01.200\nHello world\n\n0:00:01.200,0:00:02.500\nGood bye\n\n"},
+	}
+	for _, test := range tests {
+		if got := write(t, test.format, test.parameters, test.syncMap); got != test.want {
+			t.Errorf("%s %s: got\n%s\nwant\n%s", test.format, test.parameters, got, test.want)
+		}
+	}
+}
+
+func TestWrapLine(t *testing.T) {
+	tests := []struct {
+		line       string
+		lineLength int
+		want       []string
+	}{
+		{"In the beginning was the Word", 10, []string{"In the", "beginning", "was the", "Word"}},
+		{"In the beginning", 100, []string{"In the beginning"}},
+		{"Unbreakable", 4, []string{"Unbreakable"}},
+		{"été à la mer", 7, []string{"été à", "la mer"}},
+	}
+	for _, test := range tests {
+		if got := wrapLine(test.line, test.lineLength); strings.Join(got, "|") != strings.Join(test.want, "|") {
+			t.Errorf("%q at %d: got %q, want %q", test.line, test.lineLength, got, test.want)
+		}
+	}
+}
+
+func TestTtml(t *testing.T) {
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xml:lang="eng">
+ <body>
+  <div>
+   <p xml:id="f000001" begin="00:00:00.000" end="00:00:01.200">
+    Hello world
+   </p>
+   <p xml:id="f000002" begin="00:00:01.200" end="00:00:02.500">
+    Good bye
+   </p>
+  </div>
+ </body>
+</tt>
+`
+	for _, format := range []string{"ttml", "dfxp"} {
+		if got := write(t, format, "task_language=eng", testSyncMap()); got != want {
+			t.Errorf("%s: got\n%s\nwant\n%s", format, got, want)
+		}
+	}
+}
+
+func TestTextGrid(t *testing.T) {
+	want := "File type = \"ooTextFile\"\nObject class = \"TextGrid\"\n\n0.000\n3.000\n<exists>\n1\n\"IntervalTier\"\n\"level 1\"\n0.000\n3.000\n3\n" +
+		"0.000\n1.200\n\"Hello world\"\n1.200\n2.500\n\"Good bye\"\n2.500\n3.000\n\"\"\n"
+	if got := write(t, "textgrid_short", "task_language=eng", testSyncMap()); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	got := write(t, "textgrid", "task_language=eng", testMultilevelSyncMap())
+	for _, want := range []string{
+		"size = 2\n",
+		"name = \"level 1\"\n        xmin = 0.000\n        xmax = 3.000\n        intervals: size = 2\n",
+		"text = \"Hello world Good bye\"\n",
+		"name = \"level 2\"\n        xmin = 0.000\n        xmax = 3.000\n        intervals: size = 3\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q missing from\n%s", want, got)
+		}
+	}
+}
+
+func TestTextGridIntervals(t *testing.T) {
+	fragments := []*datatypes.SyncMapFragment{
+		{Begin: time.Second, End: 2 * time.Second, Text: "a"},
+		{Begin: 3 * time.Second, End: 4 * time.Second, Text: "b"},
+	}
+	intervals := textGridIntervals(fragments, 5*time.Second)
+	want := []textGridInterval{{0, time.Second, ""}, {time.Second, 2 * time.Second, "a"}, {2 * time.Second, 3 * time.Second, ""},
+		{3 * time.Second, 4 * time.Second, "b"}, {4 * time.Second, 5 * time.Second, ""}}
+	if len(intervals) != len(want) {
+		t.Fatalf("got %v, want %v", intervals, want)
+	}
+	for i := range want {
+		if intervals[i] != want[i] {
+			t.Errorf("interval %d: got %v, want %v", i, intervals[i], want[i])
+		}
+	}
+}
+
+func TestEaf(t *testing.T) {
+	got := write(t, "eaf", "task_language=eng", testMultilevelSyncMap())
+	for _, want := range []string{
+		`MIME_TYPE="audio/mpeg" RELATIVE_MEDIA_URL="./chapter.mp3"`,
+		`<TIME_SLOT TIME_SLOT_ID="ts4" TIME_VALUE="1200"/>`,
+		`<TIER LINGUISTIC_TYPE_REF="default-lt" TIER_ID="level 2">`,
+		`<ALIGNABLE_ANNOTATION ANNOTATION_ID="a3" TIME_SLOT_REF1="ts5" TIME_SLOT_REF2="ts6">`,
+		`<ANNOTATION_VALUE>Hello world Good bye</ANNOTATION_VALUE>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%s missing from\n%s", want, got)
+		}
+	}
+}
+
+func TestTables(t *testing.T) {
+	tests := []struct {
+		format     string
+		parameters string
+		want       string
+	}{
+		{"csv", "task_language=eng", "f000001,0.000,1.200,Hello world\nf000002,1.200,2.500,Good bye\n"},
+		{"tsv", "task_language=eng", "f000001\t0.000\t1.200\tHello world\nf000002\t1.200\t2.500\tGood bye\n"},
+		{"csv", "os_task_file_columns=id, duration|os_task_file_header=true|os_task_file_time_precision=1", "id,duration\nf000001,1.2\nf000002,1.3\n"},
+		{"aud", "task_language=eng", "0.000000\t1.200000\tf000001\n1.200000\t2.500000\tf000002\n"},
+		{"aud", "os_task_file_audacity_label=text", "0.000000\t1.200000\tHello world\n1.200000\t2.500000\tGood bye\n"},
+	}
+	for _, test := range tests {
+		if got := write(t, test.format, test.parameters, testSyncMap()); got != test.want {
+			t.Errorf("%s %s: got\n%s\nwant\n%s", test.format, test.parameters, got, test.want)
+		}
+	}
+
+	// Quoted when needed, children replace their parent
+	syncMap := testMultilevelSyncMap()
+	syncMap.Fragments[0].Children[0].Text = `Hello, "world"`
+	want := "p000001s000001,0.000,1.200,\"Hello, \"\"world\"\"\"\np000001s000002,1.200,2.500,Good bye\n"
+	if got := write(t, "csv", "task_language=eng", syncMap); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestXml(t *testing.T) {
+	want := `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<map>
+ <fragment begin="0.000" end="2.500" id="p000001">
+  <line>Hello world</line>
+  <line>Good bye</line>
+  <children>
+   <fragment begin="0.000" end="1.200" id="p000001s000001">
+    <line>Hello world</line>
+   </fragment>
+   <fragment begin="1.200" end="2.500" id="p000001s000002">
+    <line>Good bye</line>
+   </fragment>
+  </children>
+ </fragment>
+</map>
+`
+	if got := write(t, "xml", "task_language=eng", testMultilevelSyncMap()); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
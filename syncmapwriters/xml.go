@@ -0,0 +1,60 @@
+package syncmapwriters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+// aeneas XML sync map
+type XmlWriter struct {
+}
+
+func (xw XmlWriter) Check(task *datatypes.Task, parameters *datatypes.Parameters) error {
+	_, err := parameters.GetTimePrecision()
+	return err
+}
+
+// `<map>` of `<fragment id begin end>` elements holding one `<line>` per line of text, and
+// a `<children>` element with their child fragments when they have some
+func (xw XmlWriter) Write(task *datatypes.Task, parameters *datatypes.Parameters, syncMap *datatypes.SyncMap, output io.Writer) error {
+	precision, err := parameters.GetTimePrecision()
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(output)
+	fmt.Fprintln(writer, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>`)
+	fmt.Fprintln(writer, `<map>`)
+	writeXmlFragments(writer, syncMap.Fragments, 1, precision)
+	fmt.Fprintln(writer, `</map>`)
+	return writer.Flush()
+}
+
+func writeXmlFragments(writer *bufio.Writer, fragments []*datatypes.SyncMapFragment, depth int, precision int) {
+	indent := strings.Repeat(" ", depth)
+	for _, fragment := range fragments {
+		fmt.Fprintf(writer, "%s<fragment begin=\"%s\" end=\"%s\" id=\"%s\">\n", indent,
+			datatypes.FormatSeconds(fragment.Begin, precision), datatypes.FormatSeconds(fragment.End, precision), escapeXml(fragment.Id))
+		for _, line := range strings.Split(fragment.Text, "\n") {
+			fmt.Fprintf(writer, "%s <line>%s</line>\n", indent, escapeXml(line))
+		}
+		if len(fragment.Children) > 0 {
+			fmt.Fprintf(writer, "%s <children>\n", indent)
+			writeXmlFragments(writer, fragment.Children, depth+2, precision)
+			fmt.Fprintf(writer, "%s </children>\n", indent)
+		}
+		fmt.Fprintf(writer, "%s</fragment>\n", indent)
+	}
+}
+
+func (xw XmlWriter) GetName() string {
+	return "xml"
+}
+
+func GetXmlWriter() XmlWriter {
+	return XmlWriter{}
+}
@@ -0,0 +1,152 @@
+package vad
+
+import (
+	"math"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+// Frames whose log10 energy is at or below this value are digital silence (see mfcc.mfccFrameLogEnergy)
+const silenceLogEnergy = -10
+
+// Range of frames, End is exclusive
+type Interval struct {
+	Start int
+	End   int
+}
+
+// Settings of the detector, lengths are in frames
+type Options struct {
+	// A frame is speech when its log10 energy is at least this much above the quietest frame
+	LogEnergyThreshold float64
+	// Nonspeech runs shorter than this, between two speech runs, are considered speech
+	MinNonspeechLength int
+	// Number of frames added before and after each speech run
+	ExtendSpeechBefore int
+	ExtendSpeechAfter  int
+}
+
+// Reads the options from the task parameters, using the same keys and defaults as aeneas:
+// `vad_log_energy_threshold` (0.699), `vad_min_nonspeech_length` (0.2 seconds),
+// `vad_extend_speech_before` and `vad_extend_speech_after` (0 seconds).
+// frameRate converts the lengths from seconds to the nearest number of frames.
+func ParseOptions(parameters *datatypes.Parameters, frameRate float64) (*Options, error) {
+	options := &Options{}
+	var err error
+
+	if options.LogEnergyThreshold, err = parameters.GetFloat("vad_log_energy_threshold", 0.699); err != nil {
+		return nil, err
+	}
+
+	lengths := []struct {
+		key          string
+		defaultValue float64
+		frames       *int
+	}{
+		{"vad_min_nonspeech_length", 0.2, &options.MinNonspeechLength},
+		{"vad_extend_speech_before", 0, &options.ExtendSpeechBefore},
+		{"vad_extend_speech_after", 0, &options.ExtendSpeechAfter},
+	}
+	for _, length := range lengths {
+		seconds, err := parameters.GetFloat(length.key, length.defaultValue)
+		if err != nil {
+			return nil, err
+		}
+		*length.frames = int(math.Round(seconds * frameRate))
+	}
+
+	return options, nil
+}
+
+// Marks each frame as speech (true) or nonspeech (false) from its log10 energy
+func Detect(logEnergy []float64, options *Options) []bool {
+	speech := make([]bool, len(logEnergy))
+
+	// Digital silence would make any background noise look like speech, so it is
+	// left out when looking for the quietest frame
+	quietest := 0.0
+	found := false
+	for _, energy := range logEnergy {
+		if energy > silenceLogEnergy && (!found || energy < quietest) {
+			quietest = energy
+			found = true
+		}
+	}
+	if !found {
+		return speech
+	}
+
+	for i, energy := range logEnergy {
+		speech[i] = energy >= quietest+options.LogEnergyThreshold
+	}
+
+	// Short pauses inside speech (between words, stops) are still speech
+	for _, interval := range NonspeechIntervals(speech) {
+		if interval.Start > 0 && interval.End < len(speech) && interval.End-interval.Start < options.MinNonspeechLength {
+			fill(speech, interval.Start, interval.End)
+		}
+	}
+
+	if options.ExtendSpeechBefore > 0 || options.ExtendSpeechAfter > 0 {
+		for _, interval := range SpeechIntervals(speech) {
+			fill(speech, interval.Start-options.ExtendSpeechBefore, interval.End+options.ExtendSpeechAfter)
+		}
+	}
+
+	return speech
+}
+
+func fill(speech []bool, start int, end int) {
+	for i := max(start, 0); i < min(end, len(speech)); i++ {
+		speech[i] = true
+	}
+}
+
+// Runs of speech frames
+func SpeechIntervals(speech []bool) []Interval {
+	return intervals(speech, true)
+}
+
+// Runs of nonspeech frames
+func NonspeechIntervals(speech []bool) []Interval {
+	return intervals(speech, false)
+}
+
+func intervals(speech []bool, value bool) []Interval {
+	result := make([]Interval, 0)
+	start := -1
+	for i, isSpeech := range speech {
+		if isSpeech == value && start < 0 {
+			start = i
+		} else if isSpeech != value && start >= 0 {
+			result = append(result, Interval{Start: start, End: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		result = append(result, Interval{Start: start, End: len(speech)})
+	}
+	return result
+}
+
+// Number of frames before the narrator starts, between minFrames and maxFrames
+//
+// This is the first speech frame at or after minFrames, or maxFrames when there is no speech before it.
+func DetectHead(speech []bool, minFrames int, maxFrames int) int {
+	maxFrames = min(maxFrames, len(speech))
+	for i := max(minFrames, 0); i < maxFrames; i++ {
+		if speech[i] {
+			return i
+		}
+	}
+	return max(maxFrames, minFrames)
+}
+
+// Number of frames after the narrator stops, between minFrames and maxFrames
+func DetectTail(speech []bool, minFrames int, maxFrames int) int {
+	reversed := make([]bool, len(speech))
+	for i, isSpeech := range speech {
+		reversed[len(speech)-1-i] = isSpeech
+	}
+	return DetectHead(reversed, minFrames, maxFrames)
+}
@@ -0,0 +1,99 @@
+package vad
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sillsdev/go-aeneas/datatypes"
+)
+
+func TestParseOptions(t *testing.T) {
+	// 0.2 seconds at 66.67 frames per second is 13.33 frames, 0.1 is 6.67 and 0.05 is 3.33
+	options, err := ParseOptions(datatypes.ParseParameters("vad_extend_speech_before=0.1|vad_extend_speech_after=0.05"), 22050.0/330)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Options{LogEnergyThreshold: 0.699, MinNonspeechLength: 13, ExtendSpeechBefore: 7, ExtendSpeechAfter: 3}
+	if *options != want {
+		t.Errorf("got %+v, want %+v", *options, want)
+	}
+
+	if _, err := ParseOptions(datatypes.ParseParameters("vad_min_nonspeech_length=short"), 100); err == nil {
+		t.Error("invalid length accepted")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	// Digital silence first, then speech 1 (10 times the energy) above the quietest frames
+	logEnergy := []float64{-20, 0, 0, 1, 1, 0, 1, 1, 0, 0, 0, 0, 1}
+	tests := []struct {
+		name    string
+		options Options
+		want    string
+	}{
+		{"threshold only", Options{LogEnergyThreshold: 0.699}, "___xx_xx____x"},
+		{"short pause filled", Options{LogEnergyThreshold: 0.699, MinNonspeechLength: 2}, "___xxxxx____x"},
+		{"long pause kept", Options{LogEnergyThreshold: 0.699, MinNonspeechLength: 4}, "___xxxxx____x"},
+		{"longer pause filled", Options{LogEnergyThreshold: 0.699, MinNonspeechLength: 5}, "___xxxxxxxxxx"},
+		{"extended", Options{LogEnergyThreshold: 0.699, MinNonspeechLength: 2, ExtendSpeechBefore: 1, ExtendSpeechAfter: 2}, "__xxxxxxxx_xx"},
+		{"high threshold", Options{LogEnergyThreshold: 2}, "_____________"},
+	}
+	for _, test := range tests {
+		if got := frames(Detect(logEnergy, &test.options)); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+
+	if got := frames(Detect([]float64{-20, -20, -20}, &Options{LogEnergyThreshold: 0.699})); got != "___" {
+		t.Errorf("digital silence: got %s", got)
+	}
+}
+
+// Speech frames as x, nonspeech frames as _
+func frames(speech []bool) string {
+	text := ""
+	for _, isSpeech := range speech {
+		if isSpeech {
+			text += "x"
+		} else {
+			text += "_"
+		}
+	}
+	return text
+}
+
+func TestIntervals(t *testing.T) {
+	speech := []bool{false, true, true, false, false, true}
+	if got, want := SpeechIntervals(speech), []Interval{{1, 3}, {5, 6}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("speech: got %v, want %v", got, want)
+	}
+	if got, want := NonspeechIntervals(speech), []Interval{{0, 1}, {3, 5}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("nonspeech: got %v, want %v", got, want)
+	}
+}
+
+func TestDetectHeadTail(t *testing.T) {
+	speech := []bool{false, false, false, true, true, false, true, false, false}
+	tests := []struct {
+		name      string
+		detect    func(speech []bool, minFrames int, maxFrames int) int
+		minFrames int
+		maxFrames int
+		want      int
+	}{
+		{"head", DetectHead, 0, 5, 3},
+		{"head after the minimum", DetectHead, 4, 5, 4},
+		{"head in a pause after the minimum", DetectHead, 5, 9, 6},
+		{"no speech before the maximum", DetectHead, 0, 2, 2},
+		{"maximum past the end", DetectHead, 7, 20, 9},
+		{"minimum above the maximum", DetectHead, 5, 3, 5},
+		{"tail", DetectTail, 0, 5, 2},
+		{"tail after the minimum", DetectTail, 3, 5, 4},
+		{"no speech in the tail", DetectTail, 0, 1, 1},
+	}
+	for _, test := range tests {
+		if got := test.detect(speech, test.minFrames, test.maxFrames); got != test.want {
+			t.Errorf("%s: got %d, want %d", test.name, got, test.want)
+		}
+	}
+}